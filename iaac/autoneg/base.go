@@ -4,32 +4,58 @@ import (
 	"fmt"
 	"mlops/global"
 	"mlops/iam"
+	"strings"
 
-	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/serviceaccount"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/apps/v1"
+	rbacV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/rbac/v1"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
 )
 
+// EnableAutoNEGController deploys the AutoNEG controller, which attaches the NEGs of annotated Services to the
+// Global Load Balancer backend service. The returned Deployment is ready once Pulumi has created it, so the load
+// balancer can depend on it.
 func EnableAutoNEGController(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	k8sProvider *kubernetes.Provider,
-) (*serviceaccount.Account, error) {
+) (*v1.Deployment, error) {
 
 	// Create AutoNEG IAM Resources
 	AutoNEGServiceAccount, err := iam.CreateIAMResources(ctx, projectConfig, AutoNEGSystemIAM)
 	if err != nil {
-		return nil, fmt.Errorf("failed to configure IAM access for Auto NEG Controller end-to-end: %w", err)
+		return nil, global.NewResourceError("autoneg", "service account", err)
 	}
 
 	// Apply AutoNEG Kubernetes Deployment
-	negDeployment := createAutoNEGKubernetesResources(ctx, projectConfig, k8sProvider, AutoNEGServiceAccount)
+	metricsConsumer, err := configureMetricsConsumer(ctx)
+	if err != nil {
+		return nil, global.NewResourceError("autoneg", "configuration", err)
+	}
+	negDeployment, err := createAutoNEGKubernetesResources(ctx, projectConfig, k8sProvider, AutoNEGServiceAccount, metricsConsumer)
+	if err != nil {
+		return nil, global.NewResourceError("autoneg", "controller", err)
+	}
+	return negDeployment, nil
+}
 
-	// Ensure NEG Deployment is applied before returning
-	negDeployment.ApplyT(func(_ interface{}) string {
-		ctx.Log.Info("AutoNEG Deployment completed.", nil)
-		return "AutoNEG Deployment successful"
-	})
+// configureMetricsConsumer reads the `<namespace>/<service account>` granted the AutoNEG metrics ( `autoneg:metricsConsumer` ).
+func configureMetricsConsumer(
+	ctx *pulumi.Context,
+) (*rbacV1.SubjectArgs, error) {
 
-	return AutoNEGServiceAccount["autoneg"].ServiceAccount, nil
+	consumer := config.Get(ctx, "autoneg:metricsConsumer")
+	if consumer == "" {
+		consumer = defaultMetricsConsumer
+	}
+	parts := strings.Split(consumer, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("AutoNEG metrics consumer '%s' must be `<namespace>/<service account>`", consumer)
+	}
+	return &rbacV1.SubjectArgs{
+		Kind:      pulumi.String("ServiceAccount"),
+		Namespace: pulumi.String(parts[0]),
+		Name:      pulumi.String(parts[1]),
+	}, nil
 }
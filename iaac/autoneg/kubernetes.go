@@ -8,6 +8,7 @@ import (
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/apps/v1"
 	coreV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/core/v1"
 	metaV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/meta/v1"
+	rbacV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/rbac/v1"
 
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -15,58 +16,58 @@ import (
 
 var (
 	namespace          = "autoneg-system"
+	controllerImage    = "ghcr.io/googlecloudplatform/gke-autoneg-controller/gke-autoneg-controller:v1.1.0"
 	kubeRBACProxyImage = "gcr.io/kubebuilder/kube-rbac-proxy:v0.16.0"
+	// The Workload Identity binding of AutoNEGSystemIAM is for this Kubernetes Service Account.
+	kubeServiceAccount = "autoneg-controller-manager"
+	// Scrapes the metrics endpoint and is granted `autoneg-metrics-reader`; GKE Managed Prometheus by default.
+	defaultMetricsConsumer = "gmp-system/collector"
+	podLabels              = pulumi.StringMap{
+		"app":           pulumi.String("autoneg"),
+		"control-plane": pulumi.String("controller-manager"),
+	}
 )
 
-// createAutoNEGKubernetesResources deploys the AutoNEG system with RBAC, service account, and controller deployment
+// createAutoNEGKubernetesResources deploys the AutoNEG system with RBAC, service account, and controller deployment.
+// Pulumi waits for the Deployment to be ready, so resources depending on it wait for the controller.
 func createAutoNEGKubernetesResources(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	k8sProvider *kubernetes.Provider,
 	serviceAccount map[string]iam.ServiceAccountInfo,
-) pulumi.Output {
+	metricsConsumer *rbacV1.SubjectArgs,
+) (*v1.Deployment, error) {
 
 	// Create Namespace
 	ns, err := createNamespace(ctx, projectConfig, k8sProvider)
 	if err != nil {
-		ctx.Log.Warn("Failed to create namespace for Auto NEG Controller", nil)
-		return pulumi.StringOutput{}.ApplyT(func(_ string) string { return "Error: Namespace creation failed" })
+		return nil, fmt.Errorf("failed to create namespace for AutoNEG Controller: %w", err)
 	}
 
 	// Create Service Account
-	autoNegServiceAccount, err := createServiceAccount(ctx, projectConfig, k8sProvider, serviceAccount["AutoNEG"].Email, ns)
+	autoNegServiceAccount, err := createServiceAccount(ctx, projectConfig, k8sProvider, serviceAccount["autoneg"].Email, ns)
 	if err != nil {
-		ctx.Log.Warn("Failed to create Service Account for Auto NEG Controller", nil)
-		return pulumi.StringOutput{}.ApplyT(func(_ string) string { return "Error: Service Account creation failed" })
+		return nil, fmt.Errorf("failed to create Service Account for AutoNEG Controller: %w", err)
 	}
 
 	// Create Roles and Bindings
-	err = createAutoNEGRBAC(ctx, projectConfig, k8sProvider, autoNegServiceAccount)
+	err = createAutoNEGRBAC(ctx, projectConfig, k8sProvider, autoNegServiceAccount, metricsConsumer)
 	if err != nil {
-		ctx.Log.Warn("Failed to create RBAC for Auto NEG Controller", nil)
-		return pulumi.StringOutput{}.ApplyT(func(_ string) string { return "Error: RBAC setup failed" })
+		return nil, fmt.Errorf("failed to create RBAC for AutoNEG Controller: %w", err)
 	}
 
 	// Deploy AutoNEG Service
 	err = createAutoNegService(ctx, projectConfig, k8sProvider, ns)
 	if err != nil {
-		ctx.Log.Warn("Failed to create AutoNEG Service", nil)
-		return pulumi.StringOutput{}.ApplyT(func(_ string) string { return "Error: AutoNEG Service creation failed" })
+		return nil, fmt.Errorf("failed to create AutoNEG metrics Service: %w", err)
 	}
 
 	// Deploy AutoNEG Controller
 	negDeployment, err := createAutoNegDeployment(ctx, projectConfig, k8sProvider, autoNegServiceAccount, ns)
 	if err != nil {
-		ctx.Log.Warn("Failed to create AutoNEG Deployment", nil)
-		return pulumi.StringOutput{}.ApplyT(func(_ string) string { return "Error: AutoNEG Deployment failed" })
+		return nil, fmt.Errorf("failed to create AutoNEG Deployment: %w", err)
 	}
-
-	return negDeployment.Status.ReadyReplicas().ApplyT(func(replicaCount *int) string {
-		if replicaCount != nil && *replicaCount > 0 {
-			return fmt.Sprintf("%d replicas ready", *replicaCount)
-		}
-		return "AutoNEG deployment in progress"
-	})
+	return negDeployment, nil
 }
 
 // Create Kubernetes Namespace
@@ -101,7 +102,7 @@ func createServiceAccount(
 	return coreV1.NewServiceAccount(ctx, resourceName, &coreV1.ServiceAccountArgs{
 		Metadata: &metaV1.ObjectMetaArgs{
 			Namespace: ns.Metadata.Name(),
-			Name:      pulumi.String("autoneg-service-account"),
+			Name:      pulumi.String(kubeServiceAccount),
 			Labels: pulumi.StringMap{
 				"app": pulumi.String("autoneg"),
 			},
@@ -120,9 +121,10 @@ func createAutoNEGRBAC(
 	projectConfig global.ProjectConfig,
 	k8sProvider *kubernetes.Provider,
 	serviceAccount *coreV1.ServiceAccount,
+	metricsConsumer *rbacV1.SubjectArgs,
 ) error {
 
-	err := createClusterRoles(ctx, projectConfig, k8sProvider, serviceAccount)
+	err := createClusterRoles(ctx, projectConfig, k8sProvider, serviceAccount, metricsConsumer)
 	if err != nil {
 		return err
 	}
@@ -165,10 +167,7 @@ func createAutoNegService(
 					Protocol:   pulumi.String("TCP"),
 				},
 			},
-			Selector: pulumi.StringMap{
-				"app":           pulumi.String("autoneg"),
-				"control-plane": pulumi.String("controller-manager"),
-			},
+			Selector: podLabels,
 		},
 	},
 		pulumi.Provider(k8sProvider),
@@ -176,7 +175,7 @@ func createAutoNegService(
 	return err
 }
 
-// Deploy AutoNEG Controller
+// Deploy AutoNEG Controller, with kube-rbac-proxy in front of its metrics endpoint
 func createAutoNegDeployment(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
@@ -190,35 +189,83 @@ func createAutoNegDeployment(
 		Metadata: &metaV1.ObjectMetaArgs{
 			Namespace: ns.Metadata.Name(),
 			Name:      pulumi.String("autoneg-controller-manager"),
+			Labels:    podLabels,
 		},
 		Spec: &v1.DeploymentSpecArgs{
 			Replicas: pulumi.Int(1),
 			Selector: &metaV1.LabelSelectorArgs{
-				MatchLabels: pulumi.StringMap{
-					"app": pulumi.String("autoneg"),
-				},
+				MatchLabels: podLabels,
 			},
 			Template: &coreV1.PodTemplateSpecArgs{
 				Metadata: &metaV1.ObjectMetaArgs{
-					Labels: pulumi.StringMap{
-						"app": pulumi.String("autoneg"),
-					},
+					Labels: podLabels,
 				},
 				Spec: &coreV1.PodSpecArgs{
 					ServiceAccountName: autoNegServiceAccount.Metadata.Name(),
+					SecurityContext: &coreV1.PodSecurityContextArgs{
+						RunAsNonRoot: pulumi.Bool(true),
+					},
 					Containers: coreV1.ContainerArray{
 						&coreV1.ContainerArgs{
-							Name:  pulumi.String("manager"),
-							Image: pulumi.String(kubeRBACProxyImage),
+							Name:    pulumi.String("manager"),
+							Image:   pulumi.String(controllerImage),
+							Command: pulumi.StringArray{pulumi.String("/manager")},
 							Args: pulumi.StringArray{
 								pulumi.String("--health-probe-bind-address=:8081"),
 								pulumi.String("--metrics-bind-address=127.0.0.1:8080"),
 								pulumi.String("--leader-elect"),
+								pulumi.String("--zap-encoder=json"),
+							},
+							LivenessProbe: &coreV1.ProbeArgs{
+								HttpGet: &coreV1.HTTPGetActionArgs{
+									Path: pulumi.String("/healthz"),
+									Port: pulumi.Int(8081),
+								},
+								InitialDelaySeconds: pulumi.Int(15),
+								PeriodSeconds:       pulumi.Int(20),
+							},
+							ReadinessProbe: &coreV1.ProbeArgs{
+								HttpGet: &coreV1.HTTPGetActionArgs{
+									Path: pulumi.String("/readyz"),
+									Port: pulumi.Int(8081),
+								},
+								InitialDelaySeconds: pulumi.Int(5),
+								PeriodSeconds:       pulumi.Int(10),
+							},
+							Resources: &coreV1.ResourceRequirementsArgs{
+								Requests: pulumi.StringMap{
+									"cpu":    pulumi.String("100m"),
+									"memory": pulumi.String("64Mi"),
+								},
+								Limits: pulumi.StringMap{
+									"memory": pulumi.String("128Mi"),
+								},
+							},
+						},
+						// Serves the metrics bound to localhost over HTTPS, to callers granted `autoneg-metrics-reader`.
+						&coreV1.ContainerArgs{
+							Name:  pulumi.String("kube-rbac-proxy"),
+							Image: pulumi.String(kubeRBACProxyImage),
+							Args: pulumi.StringArray{
+								pulumi.String("--secure-listen-address=0.0.0.0:8443"),
+								pulumi.String("--upstream=http://127.0.0.1:8080/"),
+								pulumi.String("--logtostderr=true"),
+								pulumi.String("--v=0"),
 							},
 							Ports: coreV1.ContainerPortArray{
 								&coreV1.ContainerPortArgs{
-									ContainerPort: pulumi.Int(8081),
-									Name:          pulumi.String("metrics"),
+									ContainerPort: pulumi.Int(8443),
+									Name:          pulumi.String("https"),
+									Protocol:      pulumi.String("TCP"),
+								},
+							},
+							Resources: &coreV1.ResourceRequirementsArgs{
+								Requests: pulumi.StringMap{
+									"cpu":    pulumi.String("5m"),
+									"memory": pulumi.String("64Mi"),
+								},
+								Limits: pulumi.StringMap{
+									"memory": pulumi.String("128Mi"),
 								},
 							},
 						},
@@ -7,7 +7,9 @@ import (
 type ClusterRoleDefinition struct {
 	Name string
 	Bind bool
-	RBAC rbacV1.PolicyRuleArray
+	// BindMetricsConsumer binds the role to the metrics consumer instead of the controller.
+	BindMetricsConsumer bool
+	RBAC                rbacV1.PolicyRuleArray
 }
 
 type RoleDefinition struct {
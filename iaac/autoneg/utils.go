@@ -16,6 +16,7 @@ func createClusterRoles(
 	projectConfig global.ProjectConfig,
 	k8sProvider *kubernetes.Provider,
 	serviceAccount *coreV1.ServiceAccount,
+	metricsConsumer *rbacV1.SubjectArgs,
 ) error {
 
 	for _, roleDef := range ClusterRoles {
@@ -34,8 +35,19 @@ func createClusterRoles(
 			return fmt.Errorf("failed to create cluster role %s: %w", roleDef.Name, err)
 		}
 
+		controller := &rbacV1.SubjectArgs{
+			Kind:      pulumi.String("ServiceAccount"),
+			Name:      serviceAccount.Metadata.Name().Elem(),
+			Namespace: serviceAccount.Metadata.Namespace(),
+		}
 		if roleDef.Bind {
-			err = createClusterRoleBindings(ctx, projectConfig, k8sProvider, serviceAccount, roleDef.Name, clusterRole)
+			err = createClusterRoleBindings(ctx, projectConfig, k8sProvider, controller, roleDef.Name, clusterRole)
+			if err != nil {
+				return err
+			}
+		}
+		if roleDef.BindMetricsConsumer {
+			err = createClusterRoleBindings(ctx, projectConfig, k8sProvider, metricsConsumer, roleDef.Name, clusterRole)
 			if err != nil {
 				return err
 			}
@@ -49,7 +61,7 @@ func createClusterRoleBindings(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	k8sProvider *kubernetes.Provider,
-	serviceAccount *coreV1.ServiceAccount,
+	subject *rbacV1.SubjectArgs,
 	clusterRole string,
 	clusterRoleResource *rbacV1.ClusterRole,
 ) error {
@@ -68,13 +80,7 @@ func createClusterRoleBindings(
 			Kind:     pulumi.String("ClusterRole"),
 			Name:     pulumi.String(clusterRole),
 		},
-		Subjects: rbacV1.SubjectArray{
-			&rbacV1.SubjectArgs{
-				Kind:      pulumi.String("ServiceAccount"),
-				Name:      serviceAccount.Metadata.Name().Elem(),
-				Namespace: serviceAccount.Metadata.Namespace(),
-			},
-		},
+		Subjects: rbacV1.SubjectArray{subject},
 	},
 		pulumi.Provider(k8sProvider),
 		pulumi.DependsOn([]pulumi.Resource{clusterRoleResource}),
@@ -35,7 +35,8 @@ var ClusterRoles = []ClusterRoleDefinition{
 		},
 	},
 	{
-		Name: "autoneg-metrics-reader",
+		Name:                "autoneg-metrics-reader",
+		BindMetricsConsumer: true,
 		RBAC: rbacV1.PolicyRuleArray{
 			&rbacV1.PolicyRuleArgs{
 				NonResourceURLs: pulumi.StringArray{pulumi.String("/metrics")},
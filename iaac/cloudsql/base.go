@@ -1,6 +1,7 @@
 package cloudsql
 
 import (
+	"fmt"
 	"mlops/global"
 
 	"github.com/pulumi/pulumi-gcp/sdk/v6/go/gcp/sql"
@@ -8,6 +9,16 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
+var (
+	defaultDatabaseVersion = "POSTGRES_14"
+
+	// Instances are shared, per database engine, by the MLOps target and the add-ons; only the first caller
+	// creates one, every caller gets its own database and user on it.
+	sharedInstances = map[string]*sql.DatabaseInstance{}
+	// The private service networking of the VPC is created once, before the first instance.
+	networkingDependencies []pulumi.Resource
+)
+
 func DeployCloudSQL(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
@@ -15,14 +26,29 @@ func DeployCloudSQL(
 	gcpNetwork *compute.Network,
 ) (*sql.DatabaseInstance, []pulumi.Resource, error) {
 
-	dependencies, err := createServiceNetworking(ctx, projectConfig, gcpNetwork)
-	if err != nil {
-		return nil, nil, err
+	if projectConfig.CloudSQL.DatabaseVersion == "" {
+		projectConfig.CloudSQL.DatabaseVersion = defaultDatabaseVersion
+	}
+	if sharedInstance, ok := sharedInstances[projectConfig.CloudSQL.DatabaseVersion]; ok {
+		cloudSQLdependencies, err := createDatabaseUser(ctx, projectConfig, sharedInstance)
+		if err != nil {
+			return nil, nil, global.NewResourceError("cloudsql", fmt.Sprintf("database %s", projectConfig.CloudSQL.Database), err)
+		}
+		return sharedInstance, cloudSQLdependencies, nil
+	}
+
+	if networkingDependencies == nil {
+		dependencies, err := createServiceNetworking(ctx, projectConfig, gcpNetwork)
+		if err != nil {
+			return nil, nil, global.NewResourceError("cloudsql", "service networking", err)
+		}
+		networkingDependencies = dependencies
 	}
-	cloudSQL, cloudSQLdependencies, err := createCloudSQL(ctx, projectConfig, cloudRegion, gcpNetwork, dependencies)
+	cloudSQL, cloudSQLdependencies, err := createCloudSQL(ctx, projectConfig, cloudRegion, gcpNetwork, networkingDependencies)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, global.NewResourceError("cloudsql", fmt.Sprintf("%s instance", projectConfig.CloudSQL.DatabaseVersion), err)
 	}
+	sharedInstances[projectConfig.CloudSQL.DatabaseVersion] = cloudSQL
 
 	return cloudSQL, cloudSQLdependencies, nil
 }
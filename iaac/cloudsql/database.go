@@ -20,12 +20,11 @@ func createCloudSQL(
 
 	projectNamePrefix := projectConfig.ResourceNamePrefix
 	databaseInstancePrefix := projectConfig.CloudSQL.InstancePrefixName
-	cloudSQLdependencies := []pulumi.Resource{}
 	resourceName := fmt.Sprintf("%s-%s-db-instance", projectNamePrefix, databaseInstancePrefix)
 
 	dbInstance, err := sql.NewDatabaseInstance(ctx, resourceName, &sql.DatabaseInstanceArgs{
 		Name:               pulumi.Sprintf("%s-db-instance", databaseInstancePrefix),
-		DatabaseVersion:    pulumi.String("POSTGRES_14"),
+		DatabaseVersion:    pulumi.String(projectConfig.CloudSQL.DatabaseVersion),
 		Project:            pulumi.String(projectConfig.ProjectId),
 		Region:             pulumi.String(cloudRegion.Region),
 		DeletionProtection: pulumi.Bool(false),
@@ -42,13 +41,29 @@ func createCloudSQL(
 		return nil, nil, err
 	}
 
-	database, err := createDatabase(ctx, projectConfig, dbInstance)
+	cloudSQLdependencies, err := createDatabaseUser(ctx, projectConfig, dbInstance)
 	if err != nil {
 		return nil, nil, err
 	}
+
+	return dbInstance, cloudSQLdependencies, nil
+}
+
+// createDatabaseUser creates the database and user described by projectConfig.CloudSQL on the given instance
+// and fills in the connection details of the configuration.
+func createDatabaseUser(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	dbInstance *sql.DatabaseInstance,
+) ([]pulumi.Resource, error) {
+
+	database, err := createDatabase(ctx, projectConfig, dbInstance)
+	if err != nil {
+		return nil, err
+	}
 	randomPassword, databaseUser, err := createUser(ctx, projectConfig, dbInstance, database)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	projectConfig.CloudSQL.DatabaseName = database.Name
@@ -56,9 +71,7 @@ func createCloudSQL(
 	projectConfig.CloudSQL.Connection = dbInstance.FirstIpAddress
 	projectConfig.CloudSQL.Password = randomPassword.Result
 
-	cloudSQLdependencies = append(cloudSQLdependencies, database, databaseUser)
-
-	return dbInstance, cloudSQLdependencies, nil
+	return []pulumi.Resource{database, databaseUser}, nil
 }
 
 func createDatabase(
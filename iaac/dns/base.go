@@ -0,0 +1,120 @@
+package dns
+
+import (
+	"fmt"
+	"mlops/global"
+	"strings"
+
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/certificatemanager"
+	gcpDNS "github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/dns"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var (
+	recordTTL = 300
+
+	// The zone is shared by every host of the stack; it is created, or looked up, by the first record.
+	sharedZone pulumi.StringInput
+	// Hosts already recorded, e.g. one exposed by both the MLOps target and an add-on.
+	recordedHosts = map[string]bool{}
+)
+
+// CreateRecord points an A record for host at the given address in the Cloud DNS zone of the domain,
+// so certificates can be issued on the first run. It does nothing unless `dns:managedZone` or `dns:createZone` is set.
+func CreateRecord(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	host string,
+	address pulumi.StringInput,
+	opts ...pulumi.ResourceOption,
+) error {
+
+	if !projectConfig.DNS.Enabled || host == "" || recordedHosts[host] {
+		return nil
+	}
+	zone, err := managedZone(ctx, projectConfig)
+	if err != nil {
+		return err
+	}
+
+	resourceName := fmt.Sprintf("%s-dns-%s", projectConfig.ResourceNamePrefix, strings.ReplaceAll(host, ".", "-"))
+	_, err = gcpDNS.NewRecordSet(ctx, resourceName, &gcpDNS.RecordSetArgs{
+		Project:     pulumi.String(projectConfig.ProjectId),
+		ManagedZone: zone,
+		Name:        pulumi.String(host + "."),
+		Type:        pulumi.String("A"),
+		Ttl:         pulumi.Int(recordTTL),
+		Rrdatas:     pulumi.StringArray{address},
+	}, opts...)
+	if err != nil {
+		return global.NewResourceError("dns", resourceName, err)
+	}
+	recordedHosts[host] = true
+	return nil
+}
+
+// CreateAuthorizationRecord creates the CNAME record a Certificate Manager DNS authorization asks for,
+// so the certificates of the domain are issued without manual steps. It does nothing without a DNS zone.
+func CreateAuthorizationRecord(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	name string,
+	record certificatemanager.DnsAuthorizationDnsResourceRecordArrayOutput,
+	opts ...pulumi.ResourceOption,
+) error {
+
+	if !projectConfig.DNS.Enabled {
+		return nil
+	}
+	zone, err := managedZone(ctx, projectConfig)
+	if err != nil {
+		return err
+	}
+
+	// One record is returned per authorization.
+	authorizationRecord := record.Index(pulumi.Int(0))
+	resourceName := fmt.Sprintf("%s-dns-%s-authorization", projectConfig.ResourceNamePrefix, name)
+	_, err = gcpDNS.NewRecordSet(ctx, resourceName, &gcpDNS.RecordSetArgs{
+		Project:     pulumi.String(projectConfig.ProjectId),
+		ManagedZone: zone,
+		Name:        authorizationRecord.Name().Elem(),
+		Type:        authorizationRecord.Type().Elem(),
+		Ttl:         pulumi.Int(recordTTL),
+		Rrdatas:     pulumi.StringArray{authorizationRecord.Data().Elem()},
+	}, opts...)
+	if err != nil {
+		return global.NewResourceError("dns", resourceName, err)
+	}
+	return nil
+}
+
+// managedZone returns the name of the zone the records are created in, creating the zone of the domain
+// when `dns:createZone` is set.
+func managedZone(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+) (pulumi.StringInput, error) {
+
+	if sharedZone != nil {
+		return sharedZone, nil
+	}
+	if !projectConfig.DNS.CreateZone {
+		sharedZone = pulumi.String(projectConfig.DNS.ManagedZone)
+		return sharedZone, nil
+	}
+
+	resourceName := fmt.Sprintf("%s-dns-zone", projectConfig.ResourceNamePrefix)
+	zone, err := gcpDNS.NewManagedZone(ctx, resourceName, &gcpDNS.ManagedZoneArgs{
+		Project:     pulumi.String(projectConfig.ProjectId),
+		Name:        pulumi.String(resourceName),
+		DnsName:     pulumi.String(projectConfig.Domain + "."),
+		Description: pulumi.String("MLOps - Tool hostnames"),
+	})
+	if err != nil {
+		return nil, global.NewResourceError("dns", resourceName, err)
+	}
+	// The registrar of the domain must delegate to these name servers.
+	ctx.Export("dnsNameServers", zone.NameServers)
+	sharedZone = zone.Name
+	return sharedZone, nil
+}
@@ -2,6 +2,7 @@ package flux
 
 import (
 	"fmt"
+	"mlops/global"
 	"os/exec"
 
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
@@ -24,28 +25,42 @@ func DeployFlux(
 
 	githubRepo := config.Get(ctx, "ar:githubRepo")
 
+	// Resolve the chart coordinates and values overlays from `helm:flux`.
+	chart, err := global.ConfigureHelmChart(ctx, "flux", global.HelmChartConfig{
+		Chart:   helmChart,
+		Repo:    helmChartRepo,
+		Version: helmChartVersion,
+	})
+	if err != nil {
+		return global.NewResourceError("flux", "chart", err)
+	}
+	values, err := global.BuildValues(map[string]interface{}{
+		"gitRepository": map[string]interface{}{
+			"url": fmt.Sprintf("https://github.com/%s", githubRepo),
+			"ref": map[string]interface{}{
+				"branch": "main",
+			},
+		},
+	}, nil, chart.Values...)
+	if err != nil {
+		return global.NewResourceError("flux", "values", err)
+	}
+
 	// Deploy FluxCD using Helm
 	fluxHelmRelease, err := helm.NewRelease(ctx, "flux", &helm.ReleaseArgs{
-		Chart:           pulumi.String(helmChart),
-		Version:         pulumi.String(helmChartVersion),
+		Chart:           pulumi.String(chart.Chart),
+		Version:         pulumi.String(chart.Version),
 		Namespace:       pulumi.String(namespace),
 		CreateNamespace: pulumi.Bool(true),
 		RepositoryOpts: &helm.RepositoryOptsArgs{
-			Repo: pulumi.String(helmChartRepo),
+			Repo: pulumi.String(chart.Repo),
 		},
 		Timeout: pulumi.Int(600),
-		Values: pulumi.Map{
-			"gitRepository": pulumi.Map{
-				"url": pulumi.String(fmt.Sprintf("https://github.com/%s", githubRepo)),
-				"ref": pulumi.Map{
-					"branch": pulumi.String("main"),
-				},
-			},
-		},
+		Values:  values,
 	},
 		pulumi.Provider(k8sProvider))
 	if err != nil {
-		return err
+		return global.NewResourceError("flux", "release", err)
 	}
 	// Output Flux Helm Release status
 	ctx.Export("fluxHelmRelease", fluxHelmRelease.Status)
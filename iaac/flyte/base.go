@@ -11,18 +11,15 @@ import (
 
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/compute"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
-	coreV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/core/v1"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/helm/v3"
-	metaV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/meta/v1"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
 var (
-	deploy = true
-
 	application      = "flyte"
 	namespace        = "flyte"
 	helmChart        = "flyte-core"
+	binaryHelmChart  = "flyte-binary"
 	helmChartVersion = "v1.15.0"
 	helmChartRepo    = "https://flyteorg.github.io/flyte"
 	bucketName       = "flyte-project-bucket-01"
@@ -46,56 +43,109 @@ func CreateFlyteResources(
 	registryURL := fmt.Sprintf("%s/%s/%s", registryEndpoint, projectConfig.ProjectId, registryName)
 
 	infraComponents := infracomponents.InfraComponents{
-		CertManager:       true,
-		NginxIngress:      true,
-		Domain:            domain,
-		CertManagerIssuer: true,
-		Minio:             true,
+		CertManager:  true,
+		NginxIngress: true,
+		Domain:       domain,
+		Minio:        true,
 	}
 	artifactRegistryConfig := global.ArtifactRegistryConfig{
 		RegistryName:               registryName,
 		GithubServiceAccountCreate: true,
 	}
 
-	// Create IAM resources.
-	serviceAccounts, err := iam.CreateIAMResources(ctx, projectConfig, FlyteIAM)
+	// Read the Flyte mode, projects and domains.
+	flyteConfig, err := configureFlyte(ctx)
 	if err != nil {
-		return err
+		return global.NewResourceError(application, "configuration", err)
+	}
+	// The login is kept off the gRPC API and Flyte runs without its own authentication, so behind oauth2-proxy
+	// the allowlist is all that guards it.
+	if projectConfig.OAuth2Proxy.Enabled && openWhitelist(projectConfig.WhitelistedIPs) {
+		return global.NewResourceError(application, "grpc ingress", fmt.Errorf(
+			"the Flyte gRPC API is not behind oauth2-proxy and Flyte authentication is off; restrict `project:whitelistedIPs` to the flytectl and pyflyte clients"))
+	}
+	// Behind oauth2-proxy, `flyte-core` is served by Ingresses that keep the login off the gRPC API.
+	if flyteConfig.Mode == flyteModeCore && projectConfig.OAuth2Proxy.Enabled {
+		infraComponents.Ingress = true
+		infraComponents.IngressMap = coreIngressMap
+	}
+
+	// Create IAM resources; in keyless mode the workers need no key.
+	flyteIAM := flyteConfig.iamConfig()
+	if projectConfig.ArtifactRegistry.Keyless {
+		flyteIAM = iam.WithoutKeys(flyteIAM)
+	}
+	serviceAccounts, err := iam.CreateIAMResources(ctx, projectConfig, flyteIAM)
+	if err != nil {
+		return global.NewResourceError(application, "service accounts", err)
 	}
 
 	registry, err := registry.CreateArtifactRegistry(ctx, projectConfig, artifactRegistryConfig, pulumi.DependsOn([]pulumi.Resource{}))
 	if err != nil {
-		return err
+		return global.NewResourceError(application, "artifact registry", err)
 	}
-	err = createDockerRegistrySecret(ctx, projectConfig, serviceAccounts, registry, registryURL, k8sProvider)
+	projectNamespaces, err := createFlyteProjectNamespaces(ctx, projectConfig, flyteConfig, k8sProvider)
 	if err != nil {
-		return err
+		return global.NewResourceError(application, "project namespaces", err)
+	}
+	if projectConfig.ArtifactRegistry.Keyless {
+		err = grantRegistryReaders(ctx, projectConfig, flyteConfig, serviceAccounts, registry)
+	} else {
+		err = createDockerRegistrySecret(ctx, projectConfig, flyteConfig, serviceAccounts, registry, registryURL, k8sProvider, projectNamespaces)
+	}
+	if err != nil {
+		return global.NewResourceError(application, "registry access", err)
 	}
 
 	// Create the GCS bucket for object storage.
-	gcsBucket := storage.CreateObjectStorage(ctx, projectConfig, bucketName)
-	// storage.CreateObjectStorage(ctx, projectConfig, "flyte-data-01")
+	gcsBucket, err := storage.CreateObjectStorage(ctx, projectConfig, bucketName)
+	if err != nil {
+		return global.NewResourceError(application, "bucket", err)
+	}
+	// Create the dedicated buckets of the projects that request one; the task pods write their raw data there.
+	rawOutputPrefixes := pulumi.StringMap{}
+	for _, project := range flyteConfig.Projects {
+		if project.Bucket != "" {
+			projectBucket, err := storage.CreateObjectStorage(ctx, projectConfig, project.Bucket)
+			if err != nil {
+				return global.NewResourceError(application, fmt.Sprintf("project %s bucket", project.Name), err)
+			}
+			if err := grantProjectBucketAccess(ctx, projectConfig, flyteConfig, serviceAccounts, project, projectBucket); err != nil {
+				return global.NewResourceError(application, fmt.Sprintf("project %s bucket access", project.Name), err)
+			}
+		}
+		rawOutputPrefixes[project.Name] = pulumi.String(project.rawOutputPrefix(bucketName))
+	}
+	ctx.Export("flyteRawOutputPrefixes", rawOutputPrefixes)
 	// Deploy CloudSQL and obtain its dependencies.
 	cloudSQL, cloudSQLDependencies, err := cloudsql.DeployCloudSQL(ctx, projectConfig, &cloudRegion, gcpNetwork)
 	if err != nil {
-		return err
+		return global.NewResourceError(application, "database", err)
 	}
 
 	// Create other Kubernetes resources (e.g., ingress, certificates).
 	kubernetesDependencies, letsEncrypt, err := createKubernetesResources(ctx, projectConfig, infraComponents, k8sProvider, cloudSQL)
 	if err != nil {
-		return err
+		return global.NewResourceError(application, "kubernetes resources", err)
 	}
 
-	// If deploying Flyte core, add the dependencies and call the deployment.
-	if deploy {
+	// If deploying Flyte, add the dependencies and call the deployment of the selected mode.
+	if projectConfig.StageEnabled(global.StageTool) {
 		// Append the Kubernetes and CloudSQL dependencies to our dependencies slice.
 		dependencies = append(dependencies, kubernetesDependencies...)
 		dependencies = append(dependencies, cloudSQLDependencies...)
+		for _, ns := range projectNamespaces {
+			dependencies = append(dependencies, ns)
+		}
 
-		// Deploy Flyte core, ensuring it waits for the dependencies.
-		if err := deployFlyteCore(ctx, projectConfig, k8sProvider, gcsBucket.Name, domain, serviceAccounts, letsEncrypt, dependencies); err != nil {
-			return err
+		// Deploy Flyte, ensuring it waits for the dependencies.
+		if flyteConfig.Mode == flyteModeBinary {
+			err = deployFlyteBinary(ctx, projectConfig, flyteConfig, k8sProvider, gcsBucket.Name, domain, serviceAccounts, letsEncrypt, dependencies)
+		} else {
+			err = deployFlyteCore(ctx, projectConfig, flyteConfig, k8sProvider, gcsBucket.Name, domain, serviceAccounts, letsEncrypt, dependencies)
+		}
+		if err != nil {
+			return global.NewResourceError(application, fmt.Sprintf("%s release", flyteConfig.Mode), err)
 		}
 	}
 
@@ -105,6 +155,7 @@ func CreateFlyteResources(
 func deployFlyteCore(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
+	flyteConfig FlyteConfig,
 	k8sProvider *kubernetes.Provider,
 	gcsBucket pulumi.StringInput,
 	domain string,
@@ -113,98 +164,162 @@ func deployFlyteCore(
 	dependencies []pulumi.Resource,
 ) error {
 
-	// Wait for all service account emails to resolve.
-	// This ensures we have plain string values for our substitutions.
-	pulumi.All(
-		serviceAccounts["flyteadmin"].Email,
-		serviceAccounts["flytepropeller"].Email,
-		serviceAccounts["flytescheduler"].Email,
-		serviceAccounts["datacatalog"].Email,
-		serviceAccounts["flyteworkers"].Email,
-		gcsBucket,
-		projectConfig.CloudSQL.Connection,
-		projectConfig.CloudSQL.Password,
-		projectConfig.CloudSQL.DatabaseName,
-	).ApplyT(func(vals []interface{}) (interface{}, error) {
-		adminEmail := vals[0].(string)
-		propellerEmail := vals[1].(string)
-		schedulerEmail := vals[2].(string)
-		datacatalogEmail := vals[3].(string)
-		workersEmail := vals[4].(string)
-		gcsBucket := vals[5].(string)
-		dbHost := vals[6].(string)
-		dbPassword := vals[7].(string)
-		dbName := vals[8].(string)
-
-		// Path to the values.yaml file.
-		valuesFilePath := "../helm/flyte/values/values.yaml"
-		// Build the replacement map using resolved strings.
-		userSettings := map[string]interface{}{
-			"gcpProjectId":              projectConfig.ProjectId,
-			"dbHost":                    dbHost,
-			"dbPassword":                dbPassword,
-			"gcsbucket":                 gcsBucket,
-			"hostName":                  domain,
-			"AdminServiceAccount":       adminEmail,
-			"PropellerServiceAccount":   propellerEmail,
-			"SchedulerServiceAccount":   schedulerEmail,
-			"DatacatalogServiceAccount": datacatalogEmail,
-			"WorkersServiceAccount":     workersEmail,
-			"dbName":                    dbName,
-			"dbUsername":                projectConfig.CloudSQL.User,
-			"whitelistedIPs":            projectConfig.WhitelistedIPs,
-			"LetsEncrypt":               letsEncrypt,
-		}
+	// Resolve the chart coordinates and values overlays from `helm:flyte`.
+	chart, err := global.ConfigureHelmChart(ctx, "flyte", global.HelmChartConfig{
+		Chart:   helmChart,
+		Repo:    helmChartRepo,
+		Version: helmChartVersion,
+	})
+	if err != nil {
+		return err
+	}
 
-		// Get the substituted values map.
-		valuesMap, err := global.GetValues(valuesFilePath, userSettings)
-		if err != nil {
-			return nil, err
-		}
+	// Values templates, embedded or overridden from `project:valuesDir`.
+	valuesTemplates := []string{"flyte/values.yaml"}
+	// Service account emails and database outputs are substituted once resolved.
+	workersEmail := serviceAccounts["flyteworkers"].Email
+	userSettings := map[string]interface{}{
+		"gcpProjectId":              projectConfig.ProjectId,
+		"dbHost":                    projectConfig.CloudSQL.Connection,
+		"dbPassword":                projectConfig.CloudSQL.Password,
+		"gcsbucket":                 gcsBucket,
+		"hostName":                  domain,
+		"AdminServiceAccount":       serviceAccounts["flyteadmin"].Email,
+		"PropellerServiceAccount":   serviceAccounts["flytepropeller"].Email,
+		"SchedulerServiceAccount":   serviceAccounts["flytescheduler"].Email,
+		"DatacatalogServiceAccount": serviceAccounts["datacatalog"].Email,
+		"WorkersServiceAccount":     workersEmail,
+		"dbName":                    projectConfig.CloudSQL.DatabaseName,
+		"dbUsername":                projectConfig.CloudSQL.User,
+		"whitelistedIPs":            projectConfig.WhitelistedIPs,
+		"LetsEncrypt":               letsEncrypt,
+		"flyteProjects":             flyteConfig.projectNames(),
+		"flyteDomains":              flyteConfig.domainSettings(),
+		"clusterResourceCustomData": flyteConfig.clusterResourceCustomData(workersEmail),
+	}
+
+	// Behind oauth2-proxy, the chart ingresses are replaced by the Pulumi ones ( `coreIngressMap` ).
+	if projectConfig.OAuth2Proxy.Enabled {
+		valuesTemplates = append(valuesTemplates, "flyte/values-auth.yaml")
+	}
+	// nginx serves the wildcard certificate instead of one issued for the host.
+	if projectConfig.Certificates.Wildcard {
+		valuesTemplates = append(valuesTemplates, "flyte/values-wildcard.yaml")
+	}
 
-		// Deploy the Helm release for Flyte-Core.
-		resourceName := fmt.Sprintf("%s-flyte-core", projectConfig.ResourceNamePrefix)
-		flyteCoreRelease, err := helm.NewRelease(ctx, resourceName, &helm.ReleaseArgs{
-			Name:      pulumi.String(application),
-			Namespace: pulumi.String(namespace),
-			Version:   pulumi.String(helmChartVersion),
-			RepositoryOpts: &helm.RepositoryOptsArgs{
-				Repo: pulumi.String(helmChartRepo),
-			},
-			Chart:  pulumi.String(helmChart),
-			Values: valuesMap,
+	// Get the substituted values map.
+	valuesMap, err := global.GetLayeredValues(valuesTemplates, userSettings, chart.Values...)
+	if err != nil {
+		return err
+	}
+
+	// Deploy the Helm release for Flyte-Core.
+	resourceName := fmt.Sprintf("%s-flyte-core", projectConfig.ResourceNamePrefix)
+	flyteCoreRelease, err := helm.NewRelease(ctx, resourceName, &helm.ReleaseArgs{
+		Name:      pulumi.String(application),
+		Namespace: pulumi.String(namespace),
+		Version:   pulumi.String(chart.Version),
+		RepositoryOpts: &helm.RepositoryOptsArgs{
+			Repo: pulumi.String(chart.Repo),
 		},
-			pulumi.DependsOn(dependencies),
-			pulumi.Provider(k8sProvider),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to deploy Flyte-Core Helm chart: %w", err)
-		}
+		Chart:  pulumi.String(chart.Chart),
+		Values: valuesMap,
+	},
+		pulumi.DependsOn(dependencies),
+		pulumi.Provider(k8sProvider),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to deploy Flyte-Core Helm chart: %w", err)
+	}
 
-		dependencies = []pulumi.Resource{flyteCoreRelease}
-		// Configure the Service Account IAM policy.
-		configureSAIAMPolicy(ctx, projectConfig, serviceAccounts, dependencies)
-		for _, namespace := range flyteNamespaces {
-			resourceName := fmt.Sprintf("%s-%s-default-sa-patch", projectConfig.ResourceNamePrefix, namespace)
-			_, err := coreV1.NewServiceAccountPatch(ctx, resourceName, &coreV1.ServiceAccountPatchArgs{
-				Metadata: &metaV1.ObjectMetaPatchArgs{
-					Name:      pulumi.String("default"),
-					Namespace: pulumi.String(namespace),
-				},
-				ImagePullSecrets: coreV1.LocalObjectReferencePatchArray{
-					coreV1.LocalObjectReferencePatchArgs{
-						Name: pulumi.String(registrySecretName),
-					},
-				},
-			},
-				pulumi.DependsOn(dependencies),
-				pulumi.Provider(k8sProvider),
-			)
-			if err != nil {
-				return nil, fmt.Errorf("failed to patch Flyte service accounts: %w", err)
-			}
-		}
-		return nil, nil
+	if err := configureProjectNamespaces(ctx, projectConfig, flyteConfig, k8sProvider, serviceAccounts, []pulumi.Resource{flyteCoreRelease}); err != nil {
+		return err
+	}
+	return configureRawOutputPrefixes(ctx, projectConfig, flyteConfig, k8sProvider, []pulumi.Resource{flyteCoreRelease})
+}
+
+func deployFlyteBinary(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	flyteConfig FlyteConfig,
+	k8sProvider *kubernetes.Provider,
+	gcsBucket pulumi.StringInput,
+	domain string,
+	serviceAccounts map[string]iam.ServiceAccountInfo,
+	letsEncrypt string,
+	dependencies []pulumi.Resource,
+) error {
+
+	// Resolve the chart coordinates and values overlays from `helm:flyteBinary`.
+	chart, err := global.ConfigureHelmChart(ctx, "flyteBinary", global.HelmChartConfig{
+		Chart:   binaryHelmChart,
+		Repo:    helmChartRepo,
+		Version: helmChartVersion,
 	})
-	return nil
+	if err != nil {
+		return err
+	}
+
+	// Values templates, embedded or overridden from `project:valuesDir`.
+	valuesTemplates := []string{"flyte/values-binary.yaml"}
+	// Service account emails and database outputs are substituted once resolved.
+	binaryEmail := serviceAccounts[flyteConfig.workersAccount()].Email
+	userSettings := map[string]interface{}{
+		"gcpProjectId":              projectConfig.ProjectId,
+		"dbHost":                    projectConfig.CloudSQL.Connection,
+		"dbPassword":                projectConfig.CloudSQL.Password,
+		"gcsbucket":                 gcsBucket,
+		"hostName":                  domain,
+		"BinaryServiceAccount":      binaryEmail,
+		"binaryKSA":                 binaryKSA,
+		"dbName":                    projectConfig.CloudSQL.DatabaseName,
+		"dbUsername":                projectConfig.CloudSQL.User,
+		"whitelistedIPs":            projectConfig.WhitelistedIPs,
+		"LetsEncrypt":               letsEncrypt,
+		"flyteProjects":             flyteConfig.projectNames(),
+		"flyteDomains":              flyteConfig.domainSettings(),
+		"clusterResourceCustomData": flyteConfig.clusterResourceCustomData(binaryEmail),
+	}
+
+	// Behind oauth2-proxy, the console ingress requires a Google login.
+	if projectConfig.OAuth2Proxy.Enabled {
+		valuesTemplates = append(valuesTemplates, "flyte/values-binary-auth.yaml")
+		for key, value := range infracomponents.AuthSettings(projectConfig) {
+			userSettings[key] = value
+		}
+	}
+	// nginx serves the wildcard certificate instead of one issued for the host.
+	if projectConfig.Certificates.Wildcard {
+		valuesTemplates = append(valuesTemplates, "flyte/values-binary-wildcard.yaml")
+	}
+
+	// Get the substituted values map.
+	valuesMap, err := global.GetLayeredValues(valuesTemplates, userSettings, chart.Values...)
+	if err != nil {
+		return err
+	}
+
+	// Deploy the Helm release for Flyte-Binary.
+	resourceName := fmt.Sprintf("%s-flyte-binary", projectConfig.ResourceNamePrefix)
+	flyteBinaryRelease, err := helm.NewRelease(ctx, resourceName, &helm.ReleaseArgs{
+		Name:      pulumi.String(application),
+		Namespace: pulumi.String(namespace),
+		Version:   pulumi.String(chart.Version),
+		RepositoryOpts: &helm.RepositoryOptsArgs{
+			Repo: pulumi.String(chart.Repo),
+		},
+		Chart:  pulumi.String(chart.Chart),
+		Values: valuesMap,
+	},
+		pulumi.DependsOn(dependencies),
+		pulumi.Provider(k8sProvider),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to deploy Flyte-Binary Helm chart: %w", err)
+	}
+
+	if err := configureProjectNamespaces(ctx, projectConfig, flyteConfig, k8sProvider, serviceAccounts, []pulumi.Resource{flyteBinaryRelease}); err != nil {
+		return err
+	}
+	return configureRawOutputPrefixes(ctx, projectConfig, flyteConfig, k8sProvider, []pulumi.Resource{flyteBinaryRelease})
 }
@@ -0,0 +1,70 @@
+package flyte
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
+)
+
+// Flyte namespaces are named `<project>-<domain>`, so both parts must be valid DNS labels.
+var flyteNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// configureFlyte reads `flyte:mode`, `flyte:projects` and `flyte:domains` from the stack configuration,
+// falling back to the defaults when they are not set.
+func configureFlyte(
+	ctx *pulumi.Context,
+) (FlyteConfig, error) {
+
+	flyteConfig := FlyteConfig{
+		Mode: config.Get(ctx, "flyte:mode"),
+	}
+	if flyteConfig.Mode == "" {
+		flyteConfig.Mode = flyteModeCore
+	}
+	if err := config.GetObject(ctx, "flyte:projects", &flyteConfig.Projects); err != nil {
+		return flyteConfig, fmt.Errorf("failed to parse `flyte:projects`: %w", err)
+	}
+	if err := config.GetObject(ctx, "flyte:domains", &flyteConfig.Domains); err != nil {
+		return flyteConfig, fmt.Errorf("failed to parse `flyte:domains`: %w", err)
+	}
+
+	if len(flyteConfig.Projects) == 0 {
+		flyteConfig.Projects = defaultFlyteProjects
+	}
+	if len(flyteConfig.Domains) == 0 {
+		flyteConfig.Domains = defaultFlyteDomains
+	}
+	return flyteConfig, validateFlyteConfig(flyteConfig)
+}
+
+func validateFlyteConfig(
+	flyteConfig FlyteConfig,
+) error {
+
+	if flyteConfig.Mode != flyteModeCore && flyteConfig.Mode != flyteModeBinary {
+		return fmt.Errorf("Flyte mode '%s' is not supported; use `%s` or `%s`", flyteConfig.Mode, flyteModeCore, flyteModeBinary)
+	}
+
+	seen := make(map[string]bool)
+	for _, project := range flyteConfig.Projects {
+		if !flyteNameRegex.MatchString(project.Name) {
+			return fmt.Errorf("Flyte project name '%s' must be a lowercase DNS label", project.Name)
+		}
+		if seen[project.Name] {
+			return fmt.Errorf("Flyte project '%s' is defined more than once", project.Name)
+		}
+		seen[project.Name] = true
+
+		if project.Quota != nil && (project.Quota.Cpu == "" || project.Quota.Memory == "") {
+			return fmt.Errorf("Flyte project '%s' quota needs both `cpu` and `memory`", project.Name)
+		}
+	}
+	for _, domain := range flyteConfig.Domains {
+		if !flyteNameRegex.MatchString(domain) {
+			return fmt.Errorf("Flyte domain name '%s' must be a lowercase DNS label", domain)
+		}
+	}
+	return nil
+}
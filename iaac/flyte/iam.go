@@ -6,6 +6,7 @@ import (
 	"mlops/global"
 	"mlops/iam"
 
+	"github.com/pulumi/pulumi-gcp/sdk/v6/go/gcp/storage"
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/serviceaccount"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
@@ -13,23 +14,26 @@ import (
 func configureSAIAMPolicy(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
+	flyteConfig FlyteConfig,
 	serviceAccounts map[string]iam.ServiceAccountInfo,
 	dependencies []pulumi.Resource,
 ) error {
 
 	flyteKSAs := []string{"default"} // The KSA that Task Pods will use
 
-	// Compute the cartesian product (setproduct) to generate worker WI members.
+	// One worker WI member per project-domain namespace and KSA.
 	var flyteWorkerWIMembers []string
-	for _, proj := range flyteProjects {
-		for _, dom := range flyteDomains {
-			for _, ksa := range flyteKSAs {
-				// Format as "project-domain/ksa", e.g., "flytesnacks-development/default"
-				member := fmt.Sprintf("%s-%s/%s", proj, dom, ksa)
-				flyteWorkerWIMembers = append(flyteWorkerWIMembers, member)
-			}
+	for _, ns := range flyteConfig.namespaces() {
+		for _, ksa := range flyteKSAs {
+			// Format as "project-domain/ksa", e.g., "flytesnacks-development/default"
+			member := fmt.Sprintf("%s/%s", ns, ksa)
+			flyteWorkerWIMembers = append(flyteWorkerWIMembers, member)
 		}
 	}
+	// The policy is authoritative, so in `binary` mode it also carries the single binary's own KSA.
+	if flyteConfig.Mode == flyteModeBinary {
+		flyteWorkerWIMembers = append(flyteWorkerWIMembers, fmt.Sprintf("%s/%s", namespace, binaryKSA))
+	}
 
 	// Assume this comes from your GKE module (or set it manually).
 	identityNamespace := projectConfig.ProjectId
@@ -57,7 +61,7 @@ func configureSAIAMPolicy(
 
 	// Create the IAM policy for the service account.
 	_, err = serviceaccount.NewIAMPolicy(ctx, "flyte-worker-workload-identity", &serviceaccount.IAMPolicyArgs{
-		ServiceAccountId: serviceAccounts["flyteworkers"].ServiceAccount.ID(),
+		ServiceAccountId: serviceAccounts[flyteConfig.workersAccount()].ServiceAccount.ID(),
 		PolicyData:       pulumi.String(policyData),
 	}, pulumi.DependsOn(dependencies))
 	if err != nil {
@@ -65,3 +69,27 @@ func configureSAIAMPolicy(
 	}
 	return nil
 }
+
+// grantProjectBucketAccess grants the Service Account the task pods run as object access on the dedicated bucket of a project.
+func grantProjectBucketAccess(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	flyteConfig FlyteConfig,
+	serviceAccounts map[string]iam.ServiceAccountInfo,
+	project FlyteProject,
+	projectBucket *storage.Bucket,
+) error {
+
+	serviceAccount := serviceAccounts[flyteConfig.workersAccount()]
+
+	resourceName := fmt.Sprintf("%s-flyte-%s-bucket-object-admin", projectConfig.ResourceNamePrefix, project.Name)
+	_, err := storage.NewBucketIAMMember(ctx, resourceName, &storage.BucketIAMMemberArgs{
+		Bucket: projectBucket.Name,
+		Role:   pulumi.String("roles/storage.objectAdmin"),
+		Member: serviceAccount.Member.Index(pulumi.Int(0)),
+	}, pulumi.DependsOn([]pulumi.Resource{projectBucket, serviceAccount.ServiceAccount}))
+	if err != nil {
+		return fmt.Errorf("failed to grant Flyte workers access to bucket %s: %w", project.Bucket, err)
+	}
+	return nil
+}
@@ -3,10 +3,12 @@ package flyte
 import (
 	"fmt"
 	"mlops/global"
+	"mlops/iam"
 	infracomponents "mlops/infra_components"
 
 	"github.com/pulumi/pulumi-gcp/sdk/v6/go/gcp/sql"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
+	batchV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/batch/v1"
 	coreV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/core/v1"
 	metaV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/meta/v1"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -50,3 +52,148 @@ func createFlyteNamespace(
 		},
 	}, pulumi.Provider(k8sProvider))
 }
+
+// createFlyteProjectNamespaces creates one namespace per Flyte project and domain,
+// along with the project ResourceQuota when one is configured.
+func createFlyteProjectNamespaces(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	flyteConfig FlyteConfig,
+	k8sProvider *kubernetes.Provider,
+) (map[string]*coreV1.Namespace, error) {
+
+	projectNamespaces := make(map[string]*coreV1.Namespace)
+	for _, project := range flyteConfig.Projects {
+		for _, namespace := range flyteConfig.projectNamespaces(project) {
+			resourceName := fmt.Sprintf("%s-%s-ns", projectConfig.ResourceNamePrefix, namespace)
+			ns, err := coreV1.NewNamespace(ctx, resourceName, &coreV1.NamespaceArgs{
+				Metadata: &metaV1.ObjectMetaArgs{
+					Name: pulumi.String(namespace),
+					Labels: pulumi.StringMap{
+						"flyte.org/project": pulumi.String(project.Name),
+					},
+				},
+			}, pulumi.Provider(k8sProvider))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create Flyte namespace %s: %w", namespace, err)
+			}
+			projectNamespaces[namespace] = ns
+
+			if project.Quota == nil {
+				continue
+			}
+			resourceName = fmt.Sprintf("%s-%s-quota", projectConfig.ResourceNamePrefix, namespace)
+			_, err = coreV1.NewResourceQuota(ctx, resourceName, &coreV1.ResourceQuotaArgs{
+				Metadata: &metaV1.ObjectMetaArgs{
+					Name:      pulumi.String("project-quota"),
+					Namespace: ns.Metadata.Name(),
+				},
+				Spec: &coreV1.ResourceQuotaSpecArgs{
+					Hard: pulumi.StringMap{
+						"limits.cpu":    pulumi.String(project.Quota.Cpu),
+						"limits.memory": pulumi.String(project.Quota.Memory),
+					},
+				},
+			},
+				pulumi.Provider(k8sProvider),
+				pulumi.DependsOn([]pulumi.Resource{ns}),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create Flyte resource quota for %s: %w", namespace, err)
+			}
+		}
+	}
+	return projectNamespaces, nil
+}
+
+// configureProjectNamespaces binds the task pods of every project namespace to the workers Service Account
+// and, unless the registry is keyless, attaches the registry secret to their default Service Account.
+func configureProjectNamespaces(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	flyteConfig FlyteConfig,
+	k8sProvider *kubernetes.Provider,
+	serviceAccounts map[string]iam.ServiceAccountInfo,
+	dependencies []pulumi.Resource,
+) error {
+
+	// Configure the Service Account IAM policy.
+	if err := configureSAIAMPolicy(ctx, projectConfig, flyteConfig, serviceAccounts, dependencies); err != nil {
+		return err
+	}
+	// Without a registry secret there is nothing to attach to the default Service Accounts.
+	if projectConfig.ArtifactRegistry.Keyless {
+		return nil
+	}
+	for _, namespace := range flyteConfig.namespaces() {
+		resourceName := fmt.Sprintf("%s-%s-default-sa-patch", projectConfig.ResourceNamePrefix, namespace)
+		_, err := coreV1.NewServiceAccountPatch(ctx, resourceName, &coreV1.ServiceAccountPatchArgs{
+			Metadata: &metaV1.ObjectMetaPatchArgs{
+				Name:      pulumi.String("default"),
+				Namespace: pulumi.String(namespace),
+			},
+			ImagePullSecrets: coreV1.LocalObjectReferencePatchArray{
+				coreV1.LocalObjectReferencePatchArgs{
+					Name: pulumi.String(registrySecretName),
+				},
+			},
+		},
+			pulumi.DependsOn(dependencies),
+			pulumi.Provider(k8sProvider),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to patch Flyte service accounts: %w", err)
+		}
+	}
+	return nil
+}
+
+// configureRawOutputPrefixes points the executions of every project with a dedicated bucket or prefix at its raw-data
+// location. A Job sets the workflow execution config of each project domain through the flyteadmin API, so
+// adding a project stays a configuration change.
+func configureRawOutputPrefixes(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	flyteConfig FlyteConfig,
+	k8sProvider *kubernetes.Provider,
+	dependencies []pulumi.Resource,
+) error {
+
+	for _, project := range flyteConfig.Projects {
+		if project.Bucket == "" && project.Prefix == "" {
+			continue
+		}
+		resourceName := fmt.Sprintf("%s-flyte-%s-raw-output", projectConfig.ResourceNamePrefix, project.Name)
+		_, err := batchV1.NewJob(ctx, resourceName, &batchV1.JobArgs{
+			Metadata: &metaV1.ObjectMetaArgs{
+				Name:      pulumi.String(fmt.Sprintf("flyte-%s-raw-output", project.Name)),
+				Namespace: pulumi.String(namespace),
+			},
+			Spec: &batchV1.JobSpecArgs{
+				BackoffLimit: pulumi.Int(10),
+				Template: &coreV1.PodTemplateSpecArgs{
+					Spec: &coreV1.PodSpecArgs{
+						RestartPolicy: pulumi.String("OnFailure"),
+						Containers: coreV1.ContainerArray{
+							&coreV1.ContainerArgs{
+								Name:    pulumi.String("update-workflow-execution-config"),
+								Image:   pulumi.String(rawOutputJobImage),
+								Command: pulumi.StringArray{pulumi.String("sh"), pulumi.String("-c")},
+								Args:    pulumi.StringArray{pulumi.String(flyteConfig.rawOutputScript(project, bucketName))},
+							},
+						},
+					},
+				},
+			},
+		},
+			pulumi.DependsOn(dependencies),
+			pulumi.Provider(k8sProvider),
+			// The Job runs again whenever the location of the project changes.
+			pulumi.DeleteBeforeReplace(true),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to configure the raw output prefix of Flyte project %s: %w", project.Name, err)
+		}
+	}
+	return nil
+}
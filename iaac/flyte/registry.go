@@ -4,8 +4,10 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"mlops/gke"
 	"mlops/global"
 	"mlops/iam"
+	"mlops/registry"
 
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/artifactregistry"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
@@ -14,19 +16,39 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
+// grantRegistryReaders gives the node pools and the Flyte workers read access to the Flyte repository,
+// so images are pulled without a key secret.
+func grantRegistryReaders(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	flyteConfig FlyteConfig,
+	serviceAccounts map[string]iam.ServiceAccountInfo,
+	repository *artifactregistry.Repository,
+) error {
+
+	nodeMember := pulumi.Sprintf("serviceAccount:%s", gke.NodeServiceAccountEmail(projectConfig))
+	if err := registry.GrantRepositoryReader(ctx, projectConfig, repository, "flyte-nodes", nodeMember); err != nil {
+		return err
+	}
+	workersMember := pulumi.Sprintf("serviceAccount:%s", serviceAccounts[flyteConfig.workersAccount()].Email)
+	return registry.GrantRepositoryReader(ctx, projectConfig, repository, "flyte-workers", workersMember)
+}
+
 // createDockerRegistrySecret constructs the required Docker config JSON using
 // the provided service account key JSON and creates a Kubernetes secret.
 // The secret is of type "kubernetes.io/dockerconfigjson".
 func createDockerRegistrySecret(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
+	flyteConfig FlyteConfig,
 	serviceAccounts map[string]iam.ServiceAccountInfo,
 	registry *artifactregistry.Repository,
 	registryURL string,
 	k8sProvider *kubernetes.Provider,
+	projectNamespaces map[string]*coreV1.Namespace,
 ) error {
-	// Retrieve the service account info for mlrun.
-	serviceAccount := serviceAccounts["flyteworkers"]
+	// Retrieve the service account info of the task pods.
+	serviceAccount := serviceAccounts[flyteConfig.workersAccount()]
 
 	// Decode the private key using the standard library.
 	decodedPrivateKey := serviceAccount.Key.PrivateKey.ApplyT(func(encoded string) (string, error) {
@@ -65,7 +87,7 @@ func createDockerRegistrySecret(
 		return encodedData, nil
 	}).(pulumi.StringOutput)
 
-	for _, namespace := range flyteNamespaces {
+	for namespace, ns := range projectNamespaces {
 		resourceName := fmt.Sprintf("%s-%s-gcr-creds", projectConfig.ResourceNamePrefix, namespace)
 		_, err := coreV1.NewSecret(ctx, resourceName, &coreV1.SecretArgs{
 			Metadata: &metaV1.ObjectMetaArgs{
@@ -78,7 +100,7 @@ func createDockerRegistrySecret(
 			},
 		},
 			pulumi.Provider(k8sProvider),
-			pulumi.DependsOn([]pulumi.Resource{serviceAccount.ServiceAccount, registry}),
+			pulumi.DependsOn([]pulumi.Resource{serviceAccount.ServiceAccount, registry, ns}),
 		)
 		if err != nil {
 			return err
@@ -0,0 +1,22 @@
+package flyte
+
+// FlyteConfig holds the Flyte deployment mode, projects and domains read from the stack configuration.
+type FlyteConfig struct {
+	Mode     string // `core` for the multi-component chart, `binary` for the single binary
+	Projects []FlyteProject
+	Domains  []string
+}
+
+// FlyteProject describes a Flyte project; one namespace is created per project and domain.
+type FlyteProject struct {
+	Name   string             `json:"name"`
+	Quota  *FlyteProjectQuota `json:"quota,omitempty"`  // applied to every namespace of the project
+	Bucket string             `json:"bucket,omitempty"` // dedicated raw-data bucket, created by the stack
+	Prefix string             `json:"prefix,omitempty"` // prefix inside the (shared or dedicated) bucket
+}
+
+// FlyteProjectQuota holds the ResourceQuota limits of a project namespace.
+type FlyteProjectQuota struct {
+	Cpu    string `json:"cpu"`
+	Memory string `json:"memory"`
+}
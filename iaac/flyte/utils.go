@@ -2,19 +2,136 @@ package flyte
 
 import (
 	"fmt"
+	"mlops/iam"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
-func createFlyteNamespaces() []string {
+// iamConfig returns the IAM configuration of the selected Flyte mode.
+func (flyteConfig FlyteConfig) iamConfig() map[string]iam.IAM {
+	if flyteConfig.Mode == flyteModeBinary {
+		return FlyteBinaryIAM
+	}
+	return FlyteIAM
+}
+
+// openWhitelist reports whether the `project:whitelistedIPs` allowlist admits every client address.
+func openWhitelist(whitelistedIPs string) bool {
+	for _, cidr := range strings.Split(whitelistedIPs, ",") {
+		if strings.HasSuffix(strings.TrimSpace(cidr), "/0") {
+			return true
+		}
+	}
+	return false
+}
+
+// workersAccount returns the Service Account that task pods run as in the selected Flyte mode.
+func (flyteConfig FlyteConfig) workersAccount() string {
+	if flyteConfig.Mode == flyteModeBinary {
+		return "flytebinary"
+	}
+	return "flyteworkers"
+}
+
+// namespaces returns the cartesian product of the Flyte projects and domains as `<project>-<domain>`.
+func (flyteConfig FlyteConfig) namespaces() []string {
 
 	// Create a slice to hold the resulting namespaces.
 	var namespaces []string
 
-	// Compute the cartesian product of flyteProjects and flyteDomains.
-	for _, project := range flyteProjects {
-		for _, domain := range flyteDomains {
-			namespace := fmt.Sprintf("%s-%s", project, domain)
-			namespaces = append(namespaces, namespace)
-		}
+	for _, project := range flyteConfig.Projects {
+		namespaces = append(namespaces, flyteConfig.projectNamespaces(project)...)
+	}
+	return namespaces
+}
+
+// projectNamespaces returns the namespaces of a single project, one per domain.
+func (flyteConfig FlyteConfig) projectNamespaces(project FlyteProject) []string {
+
+	var namespaces []string
+	for _, domain := range flyteConfig.Domains {
+		namespaces = append(namespaces, fmt.Sprintf("%s-%s", project.Name, domain))
 	}
 	return namespaces
 }
+
+// projectNames returns the names of the configured Flyte projects.
+func (flyteConfig FlyteConfig) projectNames() []interface{} {
+
+	var names []interface{}
+	for _, project := range flyteConfig.Projects {
+		names = append(names, project.Name)
+	}
+	return names
+}
+
+// rawOutputPrefix returns the raw-data location of a project: its dedicated bucket if any,
+// else the shared Flyte bucket, optionally narrowed by the project prefix.
+func (project FlyteProject) rawOutputPrefix(sharedBucket string) string {
+
+	bucket := sharedBucket
+	if project.Bucket != "" {
+		bucket = project.Bucket
+	}
+	prefix := strings.Trim(project.Prefix, "/")
+	if prefix == "" {
+		return fmt.Sprintf("gs://%s/", bucket)
+	}
+	return fmt.Sprintf("gs://%s/%s/", bucket, prefix)
+}
+
+// adminEndpoint returns the in-cluster HTTP endpoint of the flyteadmin API in the selected Flyte mode.
+func (flyteConfig FlyteConfig) adminEndpoint() string {
+	if flyteConfig.Mode == flyteModeBinary {
+		return fmt.Sprintf("http://%s-%s-http.%s.svc.cluster.local:8080", application, binaryHelmChart, namespace)
+	}
+	return fmt.Sprintf("http://flyteadmin.%s.svc.cluster.local:80", namespace)
+}
+
+// rawOutputScript returns the shell script setting the raw output prefix of every domain of a project
+// through the project domain attributes of the flyteadmin API.
+func (flyteConfig FlyteConfig) rawOutputScript(project FlyteProject, sharedBucket string) string {
+
+	commands := []string{"set -e"}
+	for _, domain := range flyteConfig.Domains {
+		body := fmt.Sprintf(
+			`{"attributes":{"project":"%s","domain":"%s","matching_attributes":{"workflow_execution_config":{"raw_output_data_config":{"output_location_prefix":"%s"}}}}}`,
+			project.Name, domain, project.rawOutputPrefix(sharedBucket),
+		)
+		commands = append(commands, fmt.Sprintf(
+			"curl -fsS -X PUT -H 'Content-Type: application/json' -d '%s' %s/api/v1/project_domain_attributes/%s/%s",
+			body, flyteConfig.adminEndpoint(), project.Name, domain,
+		))
+	}
+	return strings.Join(commands, "\n")
+}
+
+// domainSettings returns the Flyte domains in the shape of the chart `configmap.domain.domains`.
+func (flyteConfig FlyteConfig) domainSettings() []interface{} {
+
+	var domains []interface{}
+	for _, domain := range flyteConfig.Domains {
+		domains = append(domains, map[string]interface{}{
+			"id":   domain,
+			"name": domain,
+		})
+	}
+	return domains
+}
+
+// clusterResourceCustomData returns the per-domain data of the cluster resource manager templates.
+func (flyteConfig FlyteConfig) clusterResourceCustomData(workersEmail pulumi.StringInput) []interface{} {
+
+	var customData []interface{}
+	for _, domain := range flyteConfig.Domains {
+		customData = append(customData, map[string]interface{}{
+			domain: []interface{}{
+				map[string]interface{}{
+					"gsa": map[string]interface{}{"value": workersEmail},
+				},
+			},
+		})
+	}
+	return customData
+}
@@ -3,6 +3,7 @@ package flyte
 import (
 	"mlops/global"
 	"mlops/iam"
+	infracomponents "mlops/infra_components"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
@@ -10,10 +11,18 @@ import (
 var (
 	registrySecretName = "gcr-registry-credentials"
 
-	flyteProjects = []string{"flytesnacks"}
-	flyteDomains  = []string{"development", "staging", "production"}
+	flyteModeCore   = "core"
+	flyteModeBinary = "binary"
 
-	flyteNamespaces = createFlyteNamespaces()
+	// Sets the raw output prefix of the projects through the flyteadmin API.
+	rawOutputJobImage = "curlimages/curl:8.11.1"
+
+	// The single binary runs under one Kubernetes Service Account, bound to the `flytebinary` GSA.
+	binaryKSA = "flyte-binary"
+
+	// Used when `flyte:projects` / `flyte:domains` are not configured.
+	defaultFlyteProjects = []FlyteProject{{Name: "flytesnacks"}}
+	defaultFlyteDomains  = []string{"development", "staging", "production"}
 
 	FlyteIAM = map[string]iam.IAM{
 		"flyteadmin": {
@@ -104,6 +113,68 @@ var (
 		},
 	}
 
+	// FlyteBinaryIAM replaces FlyteIAM in `binary` mode: the single binary and the task pods share one Service Account.
+	FlyteBinaryIAM = map[string]iam.IAM{
+		"flytebinary": {
+			Permissions: pulumi.StringArray{
+				pulumi.String("iam.serviceAccounts.signBlob"),
+				pulumi.String("storage.buckets.get"),
+				pulumi.String("storage.objects.create"),
+				pulumi.String("storage.objects.delete"),
+				pulumi.String("storage.objects.get"),
+				pulumi.String("storage.objects.list"),
+				pulumi.String("storage.objects.getIamPolicy"),
+				pulumi.String("storage.objects.update"),
+			},
+			CreateRole:           true,
+			CreateServiceAccount: true,
+			RoleBindings:         []string{"roles/artifactregistry.reader"},
+			ResourceNamePrefix:   "flyte",
+			CreateKey:            true,
+		},
+		"artifactregistry-writer": {
+			CreateRole:           false,
+			CreateServiceAccount: true,
+			RoleBindings:         []string{"roles/artifactregistry.writer"},
+			ResourceNamePrefix:   "flyte",
+		},
+	}
+
+	// Ingresses of `flyte-core` behind oauth2-proxy, replacing those of the chart, which share their annotations:
+	// only the console and HTTP API require a Google login, the gRPC API used by flytectl and pyflyte is only
+	// guarded by `project:whitelistedIPs`, which must not be open.
+	coreIngressMap = map[string]infracomponents.IngressConfig{
+		"flyte-core": {
+			DNS: application,
+			Paths: []infracomponents.IngressPathConfig{
+				{Path: "console", Service: "flyteconsole", Port: 80},
+				{Path: "api", Service: "flyteadmin", Port: 80},
+				{Path: "healthcheck", Service: "flyteadmin", Port: 80},
+				{Path: "v1", Service: "flyteadmin", Port: 80},
+				{Path: ".well-known", Service: "flyteadmin", Port: 80},
+				{Path: "login", Service: "flyteadmin", Port: 80},
+				{Path: "logout", Service: "flyteadmin", Port: 80},
+				{Path: "callback", Service: "flyteadmin", Port: 80},
+				{Path: "me", Service: "flyteadmin", Port: 80},
+				{Path: "config", Service: "flyteadmin", Port: 80},
+				{Path: "oauth2", Service: "flyteadmin", Port: 80},
+			},
+		},
+		"flyte-core-grpc": {
+			DNS: application,
+			Paths: []infracomponents.IngressPathConfig{
+				{Path: "flyteidl.service.AdminService", Service: "flyteadmin", Port: 81},
+				{Path: "flyteidl.service.DataProxyService", Service: "flyteadmin", Port: 81},
+				{Path: "flyteidl.service.AuthMetadataService", Service: "flyteadmin", Port: 81},
+				{Path: "flyteidl.service.IdentityService", Service: "flyteadmin", Port: 81},
+				{Path: "flyteidl.service.SignalService", Service: "flyteadmin", Port: 81},
+				{Path: "grpc.health.v1.Health", Service: "flyteadmin", Port: 81},
+			},
+			Public: true,
+			GRPC:   true,
+		},
+	}
+
 	cloudSQLConfig = global.CloudSQLConfig{
 		User:               "flyteadmin",
 		Database:           "flyte",
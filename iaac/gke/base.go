@@ -5,7 +5,6 @@ import (
 	"mlops/global"
 	"mlops/iam"
 
-	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/container"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
@@ -13,32 +12,48 @@ import (
 // CreateGKE creates a Google Kubernetes Engine (GKE) cluster along with its associated node pool and Kubernetes provider for managing the cluster.
 // This function sets up the cluster, initializes the node pool, and creates a Kubernetes provider using the generated kubeconfig.
 // The Kubernetes provider is used to interact with the created GKE cluster.
+// It also returns the zones the nodes run in; an Autopilot cluster ( `gke:mode` ) has no node pools and spans the cluster zones.
 func CreateGKEResources(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	gcpNetwork pulumi.StringInput,
 	gcpSubnetwork pulumi.StringInput,
-) (*kubernetes.Provider, *container.NodePool, error) {
+) (*kubernetes.Provider, pulumi.StringArrayOutput, error) {
 
 	cloudRegion := projectConfig.EnabledRegion
-	config := Configuration(ctx)
-
-	serviceAccount, err := iam.CreateIAMResources(ctx, projectConfig, AdministrationIAM)
+	config, err := Configuration(ctx, cloudRegion.Region)
 	if err != nil {
-		return nil, nil, err
+		return nil, pulumi.StringArrayOutput{}, global.NewResourceError(subsystem, "node pools", err)
 	}
-	gcpGKECluster, k8sProvider, err := createGKE(ctx, projectConfig, &cloudRegion, gcpNetwork, gcpSubnetwork)
+
+	serviceAccount, err := iam.CreateIAMResources(ctx, projectConfig, AdministrationIAM)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create GKE: %w", err)
+		return nil, pulumi.StringArrayOutput{}, global.NewResourceError(subsystem, "node service account", err)
 	}
-	GKENodePools, err := createGKENodePool(ctx, config, projectConfig, &cloudRegion, gcpGKECluster.ID(), serviceAccount)
+	gcpGKECluster, err := createGKE(ctx, projectConfig, &cloudRegion, gcpNetwork, gcpSubnetwork, serviceAccount)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create GKE Node Pool: %w", err)
+		return nil, pulumi.StringArrayOutput{}, global.NewResourceError(subsystem, cloudRegion.GKEClusterName, err)
 	}
 
-	baseNodePool, exists := GKENodePools["base"]
-	if !exists {
-		return nil, nil, fmt.Errorf("base node pool not found")
+	nodeZones := gcpGKECluster.NodeLocations
+	var nodePools []pulumi.Resource
+	if projectConfig.GKEMode != global.GKEModeAutopilot {
+		GKENodePools, err := createGKENodePool(ctx, config, projectConfig, &cloudRegion, gcpGKECluster.ID(), serviceAccount)
+		if err != nil {
+			return nil, pulumi.StringArrayOutput{}, global.NewResourceError(subsystem, "node pools", err)
+		}
+		baseNodePool, exists := GKENodePools[baseNodePoolKey]
+		if !exists {
+			return nil, pulumi.StringArrayOutput{}, global.NewResourceError(subsystem, "node pools", fmt.Errorf("base node pool not found"))
+		}
+		nodeZones = baseNodePool.NodeLocations
+		for _, nodePool := range GKENodePools {
+			nodePools = append(nodePools, nodePool)
+		}
+	}
+	k8sProvider, err := createKubernetesProvider(ctx, cloudRegion.GKEClusterName, gcpGKECluster, nodePools)
+	if err != nil {
+		return nil, pulumi.StringArrayOutput{}, global.NewResourceError(subsystem, "kubernetes provider", err)
 	}
-	return k8sProvider, baseNodePool, err
+	return k8sProvider, nodeZones, nil
 }
@@ -1,7 +1,12 @@
 package gke
 
 import (
-	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/container"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
 )
@@ -14,9 +19,13 @@ var (
 // Configuration reads and applies configuration values for the GKE cluster
 func Configuration(
 	ctx *pulumi.Context,
-) *ClusterConfig {
+	region string,
+) (*ClusterConfig, error) {
 
-	nodePoolConfigs := configureNodePools(ctx)
+	nodePoolConfigs, err := configureNodePools(ctx, region)
+	if err != nil {
+		return nil, err
+	}
 	management := ManagementConfig{
 		AutoRepair:  config.GetBool(ctx, "gke:managementAutoRepair"),
 		AutoUpgrade: config.GetBool(ctx, "gke:managementAutoUpgrade"),
@@ -37,76 +46,278 @@ func Configuration(
 		clusterConfig.Cidr = GKEDefaultCIDR
 	}
 
-	return clusterConfig
+	return clusterConfig, nil
+}
+
+// configureNodePools reads the node pools of the cluster ( `gke:nodePools` ), keyed by pool name, applies the
+// defaults of the fields left unset and validates them. Stacks without `gke:nodePools` get the base, highmem and
+// highcpu pools, with the legacy `gke:nodePool*` keys applied.
+func configureNodePools(
+	ctx *pulumi.Context,
+	region string,
+) (NodePoolConfigs, error) {
+
+	var legacyKeys []string
+	for _, key := range legacyNodePoolKeys {
+		if config.Get(ctx, key) != "" {
+			legacyKeys = append(legacyKeys, key)
+		}
+	}
+
+	nodePools := NodePoolConfigs{}
+	if err := config.GetObject(ctx, "gke:nodePools", &nodePools); err != nil {
+		return nil, fmt.Errorf("failed to parse `gke:nodePools`: %w", err)
+	}
+	if len(nodePools) > 0 && len(legacyKeys) > 0 {
+		return nil, fmt.Errorf("%v cannot be combined with `gke:nodePools`; move them into its entries", legacyKeys)
+	}
+	if len(nodePools) == 0 {
+		nodePools = legacyNodePools(ctx)
+		if len(legacyKeys) > 0 {
+			ctx.Log.Warn(fmt.Sprintf("%v are deprecated; define the node pools through `gke:nodePools`.", legacyKeys), nil)
+		}
+	}
+	if _, ok := nodePools[baseNodePoolKey]; !ok {
+		return nil, fmt.Errorf("`gke:nodePools` must define the `%s` node pool", baseNodePoolKey)
+	}
+
+	keys := make([]string, 0, len(nodePools))
+	for key := range nodePools {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	configuredNodePools := make(NodePoolConfigs)
+	for _, key := range keys {
+		np := applyNodePoolDefaults(nodePools[key])
+		// Set the KeyName based on the map key.
+		if key == baseNodePoolKey {
+			np.KeyName = ""
+		} else {
+			np.KeyName = key
+		}
+		errs = append(errs, validateNodePool(key, np, region))
+		configuredNodePools[key] = np
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return configuredNodePools, nil
+}
+
+// legacyNodePools returns the default node pools, with the base pool overridden by the `gke:nodePool*` keys.
+func legacyNodePools(
+	ctx *pulumi.Context,
+) NodePoolConfigs {
+
+	nodePools := make(NodePoolConfigs)
+	for key, np := range defaultNodePools {
+		nodePools[key] = np
+	}
+
+	base := nodePools[baseNodePoolKey]
+	if machineType := config.Get(ctx, "gke:nodePoolMachineType"); machineType != "" {
+		base.MachineType = machineType
+	}
+	if diskSizeGb := config.GetInt(ctx, "gke:nodePoolDiskSizeGb"); diskSizeGb != 0 {
+		base.DiskSizeGb = diskSizeGb
+	}
+	if diskType := config.Get(ctx, "gke:nodePoolDiskType"); diskType != "" {
+		base.DiskType = diskType
+	}
+	if maxNodeCount := config.GetInt(ctx, "gke:nodePoolMaxNodeCount"); maxNodeCount != 0 {
+		base.MaxNodeCount = maxNodeCount
+	}
+	base.Preemptible = config.GetBool(ctx, "gke:nodePoolPreemptible")
+	nodePools[baseNodePoolKey] = base
+	return nodePools
 }
 
-// configureNodePools reads the base configuration from Pulumi, then merges it with the specific overrides.
-func configureNodePools(ctx *pulumi.Context) NodePoolConfigs {
-	// Initialize NodePoolConfig with defaults.
-	defaultNodePool := NodePoolConfig{
-		MachineType:  "e2-standard-4",
-		DiskSizeGb:   100,
-		MaxNodeCount: 5,
-		DiskType:     "pd-standard",
-	}
-
-	// Read the base configuration from Pulumi.
-	base := NodePoolConfig{
-		MachineType:      config.Get(ctx, "gke:nodePoolMachineType"),
-		DiskSizeGb:       config.GetInt(ctx, "gke:nodePoolDiskSizeGb"),
-		DiskType:         config.Get(ctx, "gke:nodePoolDiskType"),
-		InitialNodeCount: 1,
-		MinNodeCount:     3,
-		MaxNodeCount:     config.GetInt(ctx, "gke:nodePoolMaxNodeCount"),
-		Preemptible:      config.GetBool(ctx, "gke:nodePoolPreemptible"),
-		// Assuming LocationPolicy is a field of NodePoolConfig.
-		LocationPolicy: "BALANCED",
-	}
-
-	// Merge the base config with the overrides.
-	allNodePools := mergeNodePoolConfigs(
-		NodePoolConfigs{"base": base},
-		nodePoolsConfig,
-	)
-
-	// Create a new map to hold the final merged configurations.
-	mergedConfigs := make(NodePoolConfigs)
-
-	// Iterate over all merged node pool configurations.
-	for key, np := range allNodePools {
-		// Apply defaults for any missing values.
+// applyNodePoolDefaults fills the fields of a node pool left unset. GPU pools scale from zero, and their nodes are
+// labelled `dedicated: gpu` and tainted with `nvidia.com/gpu` unless other labels and taints are set.
+func applyNodePoolDefaults(
+	np NodePoolConfig,
+) NodePoolConfig {
+
+	if np.GPU != nil {
 		if np.MachineType == "" {
-			np.MachineType = defaultNodePool.MachineType
+			np.MachineType = gpuDefaultMachineType
 		}
-		if np.DiskSizeGb == 0 {
-			np.DiskSizeGb = defaultNodePool.DiskSizeGb
+		if len(np.Labels) == 0 {
+			np.Labels = map[string]string{"dedicated": "gpu"}
 		}
-		if np.MaxNodeCount == 0 {
-			np.MaxNodeCount = defaultNodePool.MaxNodeCount
+		if !slices.ContainsFunc(np.Taints, func(taint NodePoolTaint) bool { return taint.Key == gpuTaintKey }) {
+			np.Taints = append(np.Taints, NodePoolTaint{Key: gpuTaintKey, Value: "present", Effect: "NoSchedule"})
 		}
-		if np.DiskType == "" {
-			np.DiskType = defaultNodePool.DiskType
+		if np.GPU.Count == 0 {
+			np.GPU.Count = gpuDefaultCount
 		}
-		if np.Metadata == nil {
-			np.Metadata = pulumi.StringMap{
-				"disable-legacy-endpoints": pulumi.String("true"),
-			}
+		if np.GPU.DriverVersion == "" {
+			np.GPU.DriverVersion = gpuDefaultDriverVersion
 		}
-		if np.WorkloadMetadataConfig == nil {
-			np.WorkloadMetadataConfig = &container.NodePoolNodeConfigWorkloadMetadataConfigArgs{
-				Mode: pulumi.String("GKE_METADATA"),
-			}
+		if np.GPU.Sharing == gpuSharingTimeSharing && np.GPU.MaxSharedClients == 0 {
+			np.GPU.MaxSharedClients = gpuDefaultSharedClients
 		}
+	}
+	if np.MachineType == "" {
+		np.MachineType = nodePoolDefaultMachineType
+	}
+	if np.DiskSizeGb == 0 {
+		np.DiskSizeGb = nodePoolDefaultDiskSizeGb
+	}
+	if np.DiskType == "" {
+		np.DiskType = nodePoolDefaultDiskType
+	}
+	if np.MaxNodeCount == 0 {
+		np.MaxNodeCount = nodePoolDefaultMaxNodeCount
+	}
+	if np.InitialNodeCount == 0 {
+		np.InitialNodeCount = np.MinNodeCount
+	}
+	if np.LocationPolicy == "" {
+		np.LocationPolicy = nodePoolDefaultLocationPolicy
+	}
+	if np.UpgradeSettings != nil && np.UpgradeSettings.Strategy == "" {
+		np.UpgradeSettings.Strategy = upgradeStrategySurge
+	}
+	return np
+}
 
-		// Set the KeyName based on the map key.
-		if key == "base" {
-			np.KeyName = ""
-		} else {
-			np.KeyName = key
+// validateNodePool returns every invalid field of a node pool.
+func validateNodePool(
+	name string,
+	np NodePoolConfig,
+	region string,
+) error {
+
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("node pool '%s': %s", name, fmt.Sprintf(format, args...)))
+	}
+
+	if !nodePoolNameRegex.MatchString(name) {
+		invalid("the name must be a lowercase DNS label")
+	}
+	if !machineTypeRegex.MatchString(np.MachineType) {
+		invalid("machine type '%s' is not a Compute Engine machine type, e.g. `e2-standard-4`", np.MachineType)
+	}
+	if np.DiskSizeGb < 10 {
+		invalid("`diskSizeGb` must be at least 10")
+	}
+	if !slices.Contains(nodePoolDiskTypes, np.DiskType) {
+		invalid("disk type '%s' is not supported; use one of %v", np.DiskType, nodePoolDiskTypes)
+	}
+	if np.ImageType != "" && !slices.Contains(nodePoolImageTypes, np.ImageType) {
+		invalid("image type '%s' is not supported; use one of %v", np.ImageType, nodePoolImageTypes)
+	}
+	if np.LocalSsdCount < 0 {
+		invalid("`localSsdCount` cannot be negative")
+	}
+	if np.Spot && np.Preemptible {
+		invalid("`spot` and `preemptible` are mutually exclusive")
+	}
+
+	if np.MinNodeCount < 0 {
+		invalid("`minNodeCount` cannot be negative")
+	}
+	if np.MaxNodeCount < 1 || np.MaxNodeCount < np.MinNodeCount {
+		invalid("`maxNodeCount` must be at least 1 and at least `minNodeCount`")
+	}
+	if np.InitialNodeCount < 0 || np.InitialNodeCount > np.MaxNodeCount {
+		invalid("`initialNodeCount` must be between 0 and `maxNodeCount`")
+	}
+	if name == baseNodePoolKey && np.InitialNodeCount == 0 && np.MinNodeCount == 0 {
+		invalid("the base pool must start with at least one node")
+	}
+	if !slices.Contains(nodePoolLocationPolicies, np.LocationPolicy) {
+		invalid("location policy '%s' is not supported; use one of %v", np.LocationPolicy, nodePoolLocationPolicies)
+	}
+	zoneRegex := regexp.MustCompile(fmt.Sprintf(`^%s-[a-z]$`, regexp.QuoteMeta(region)))
+	for _, location := range np.Locations {
+		if !zoneRegex.MatchString(location) {
+			invalid("location '%s' is not a zone of the cluster region '%s'", location, region)
 		}
+	}
 
-		mergedConfigs[key] = np
+	labelKeys := make([]string, 0, len(np.Labels))
+	for key := range np.Labels {
+		labelKeys = append(labelKeys, key)
+	}
+	sort.Strings(labelKeys)
+	for _, key := range labelKeys {
+		if !labelKeyRegex.MatchString(key) || !labelValueRegex.MatchString(np.Labels[key]) {
+			invalid("label '%s: %s' is not a valid Kubernetes label", key, np.Labels[key])
+		}
+	}
+	for _, taint := range np.Taints {
+		if !labelKeyRegex.MatchString(taint.Key) || !labelValueRegex.MatchString(taint.Value) {
+			invalid("taint '%s=%s' is not a valid Kubernetes taint", taint.Key, taint.Value)
+		}
+		if _, ok := nodePoolTaintEffects[taint.Effect]; !ok {
+			invalid("taint '%s' effect '%s' is not supported; use `NoSchedule`, `PreferNoSchedule` or `NoExecute`", taint.Key, taint.Effect)
+		}
+	}
+
+	if upgrade := np.UpgradeSettings; upgrade != nil {
+		switch upgrade.Strategy {
+		case upgradeStrategySurge:
+			if upgrade.MaxSurge < 0 || upgrade.MaxUnavailable < 0 || upgrade.MaxSurge+upgrade.MaxUnavailable == 0 {
+				invalid("surge upgrades need `maxSurge` or `maxUnavailable` greater than 0, and neither negative")
+			}
+		case upgradeStrategyBlueGreen:
+			if upgrade.BatchNodeCount < 0 {
+				invalid("`batchNodeCount` cannot be negative")
+			}
+			if upgrade.SoakDuration != "" && !soakDurationRegex.MatchString(upgrade.SoakDuration) {
+				invalid("soak duration '%s' must be in seconds, e.g. `600s`", upgrade.SoakDuration)
+			}
+		default:
+			invalid("upgrade strategy '%s' is not supported; use `%s` or `%s`", upgrade.Strategy, upgradeStrategySurge, upgradeStrategyBlueGreen)
+		}
+	}
+
+	if np.GPU != nil {
+		errs = append(errs, validateGPUConfig(name, *np.GPU))
+	}
+	return errors.Join(errs...)
+}
+
+// validateGPUConfig returns every invalid field of the accelerators of a node pool.
+func validateGPUConfig(
+	name string,
+	gpu GPUConfig,
+) error {
+
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("node pool '%s': %s", name, fmt.Sprintf(format, args...)))
 	}
 
-	return mergedConfigs
+	if gpu.Type == "" {
+		invalid("GPU accelerator `type` is required, e.g. `nvidia-l4`")
+	}
+	if gpu.Count < 1 {
+		invalid("GPU `count` must be at least 1 per node")
+	}
+	if _, ok := gpuDriverVersions[gpu.DriverVersion]; !ok {
+		invalid("GPU driver version '%s' is not supported; use `default`, `latest` or `disabled`", gpu.DriverVersion)
+	}
+	switch gpu.Sharing {
+	case "":
+	case gpuSharingTimeSharing:
+		if gpu.MaxSharedClients < 2 {
+			invalid("GPU time-sharing needs `maxSharedClients` of at least 2")
+		}
+	case gpuSharingMIG:
+		if gpu.PartitionSize == "" {
+			invalid("GPU MIG sharing needs a `partitionSize`, e.g. `1g.5gb`")
+		}
+	default:
+		invalid("GPU sharing '%s' is not supported; use `%s` or `%s`", gpu.Sharing, gpuSharingTimeSharing, gpuSharingMIG)
+	}
+	if gpu.PartitionSize != "" && gpu.Sharing != gpuSharingMIG {
+		invalid("GPU `partitionSize` only applies to `mig` sharing")
+	}
+	return errors.Join(errs...)
 }
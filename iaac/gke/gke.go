@@ -15,18 +15,22 @@ var (
 	GKEDeletionProtection    = false // We set this to false since we need to be able to destroy the cluster without interuptions, else the `pulumi destroy` will fail
 	GKERemoveDefaultNodePool = true
 	GKEReleaseChannel        = "REGULAR"
+	// Autopilot nodes get the broad scope; the node Service Account roles restrict what they can do.
+	autopilotOauthScope = "https://www.googleapis.com/auth/cloud-platform"
 )
 
 // createGKE sets up the Google Kubernetes Engine (GKE) cluster in the specified region using the provided network, subnetwork, and project details.
 // The function configures various cluster settings such as authorized networks, workload identity, and vertical pod autoscaling.
 // It returns the created GKE cluster object, which is used by subsequent resources such as node pools and Kubernetes providers.
+// In Autopilot mode ( `gke:mode` ) GKE provisions the nodes itself, running them as the node Service Account.
 func createGKE(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	cloudRegion *global.CloudRegion,
 	gcpNetwork pulumi.StringInput,
 	gcpSubnetwork pulumi.StringInput,
-) (*container.Cluster, *kubernetes.Provider, error) {
+	serviceAccount map[string]iam.ServiceAccountInfo,
+) (*container.Cluster, error) {
 
 	privateNodesEnabled := config.GetBool(ctx, "gke:privateNodes")
 
@@ -44,51 +48,61 @@ func createGKE(
 		}
 	}
 	cloudRegion.GKEClusterName = fmt.Sprintf("%s-gke-%s", projectConfig.ResourceNamePrefix, cloudRegion.Region)
-	gcpGKECluster, err := container.NewCluster(ctx, cloudRegion.GKEClusterName, &container.ClusterArgs{
-		Project:               pulumi.String(projectConfig.ProjectId),
-		Name:                  pulumi.String(cloudRegion.GKEClusterName),
-		Network:               gcpNetwork,
-		Subnetwork:            gcpSubnetwork,
-		Location:              pulumi.String(cloudRegion.Region), // Since we are providing a region, the cluster will be regional
-		DeletionProtection:    pulumi.Bool(GKEDeletionProtection),
-		RemoveDefaultNodePool: pulumi.Bool(GKERemoveDefaultNodePool),
-		InitialNodeCount:      pulumi.Int(1),
+	clusterArgs := &container.ClusterArgs{
+		Project:            pulumi.String(projectConfig.ProjectId),
+		Name:               pulumi.String(cloudRegion.GKEClusterName),
+		Network:            gcpNetwork,
+		Subnetwork:         gcpSubnetwork,
+		Location:           pulumi.String(cloudRegion.Region), // Since we are providing a region, the cluster will be regional
+		DeletionProtection: pulumi.Bool(GKEDeletionProtection),
 		// EnableShieldedNodes:   pulumi.Bool(privateNodesEnabled),
 		PrivateClusterConfig: privateClusterConfig,
-		VerticalPodAutoscaling: &container.ClusterVerticalPodAutoscalingArgs{
-			Enabled: pulumi.Bool(true),
-		},
 		ReleaseChannel: &container.ClusterReleaseChannelArgs{
 			Channel: pulumi.String(GKEReleaseChannel),
 		},
 		WorkloadIdentityConfig: &container.ClusterWorkloadIdentityConfigArgs{
 			WorkloadPool: pulumi.String(fmt.Sprintf("%s.svc.id.goog", projectConfig.ProjectId)),
 		},
+		LoggingService:    pulumi.String("logging.googleapis.com/kubernetes"),
+		MonitoringService: pulumi.String("monitoring.googleapis.com/kubernetes"),
+	}
+	if projectConfig.GKEMode == global.GKEModeAutopilot {
+		// Vertical Pod Autoscaling and the Persistent Disk CSI driver are always enabled on Autopilot.
+		clusterArgs.EnableAutopilot = pulumi.Bool(true)
+		clusterArgs.ClusterAutoscaling = &container.ClusterClusterAutoscalingArgs{
+			AutoProvisioningDefaults: &container.ClusterClusterAutoscalingAutoProvisioningDefaultsArgs{
+				ServiceAccount: serviceAccount["admin"].ServiceAccount.Email,
+				OauthScopes:    pulumi.StringArray{pulumi.String(autopilotOauthScope)},
+			},
+		}
+	} else {
+		clusterArgs.RemoveDefaultNodePool = pulumi.Bool(GKERemoveDefaultNodePool)
+		clusterArgs.InitialNodeCount = pulumi.Int(1)
+		clusterArgs.VerticalPodAutoscaling = &container.ClusterVerticalPodAutoscalingArgs{
+			Enabled: pulumi.Bool(true),
+		}
 		// HorizontalPodAutoscaling & HttpLoadBalancing are also enabled by default
-		AddonsConfig: &container.ClusterAddonsConfigArgs{
+		clusterArgs.AddonsConfig = &container.ClusterAddonsConfigArgs{
 			ConfigConnectorConfig: &container.ClusterAddonsConfigConfigConnectorConfigArgs{
 				Enabled: pulumi.Bool(projectConfig.Target == "management"),
 			},
 			GcePersistentDiskCsiDriverConfig: &container.ClusterAddonsConfigGcePersistentDiskCsiDriverConfigArgs{
 				Enabled: pulumi.Bool(true),
 			},
-		},
-		LoggingService:    pulumi.String("logging.googleapis.com/kubernetes"),
-		MonitoringService: pulumi.String("monitoring.googleapis.com/kubernetes"),
-	})
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create Kubernetes Cluster: %w", err)
+		}
 	}
-	// ctx.Export("kubeconfig", generateKubeconfig(gcpGKECluster.Endpoint, gcpGKECluster.Name, gcpGKECluster.MasterAuth))
-	k8sProvider, err := createKubernetesProvider(ctx, cloudRegion.GKEClusterName, gcpGKECluster)
+	gcpGKECluster, err := container.NewCluster(ctx, cloudRegion.GKEClusterName, clusterArgs,
+		pulumi.DependsOn([]pulumi.Resource{serviceAccount["admin"].ServiceAccount}),
+	)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create Kubernetes Provider configuration: %w", err)
+		return nil, fmt.Errorf("failed to create Kubernetes Cluster: %w", err)
 	}
-	return gcpGKECluster, k8sProvider, nil
+	// ctx.Export("kubeconfig", generateKubeconfig(gcpGKECluster.Endpoint, gcpGKECluster.Name, gcpGKECluster.MasterAuth))
+	return gcpGKECluster, nil
 }
 
-// createGKENodePool creates a node pool within the specified GKE cluster. It configures the node pool with settings such as machine type, preemptibility,
-// and service account credentials. The node pool also supports autoscaling with the specified minimum and maximum node count.
+// createGKENodePool creates the node pools of `gke:nodePools` within the specified GKE cluster. It configures each node pool with settings such as machine type,
+// spot or preemptible nodes, taints, accelerators and service account credentials. The node pool also supports autoscaling with the specified minimum and maximum node count.
 // This function returns the created node pool object, which is used to manage the nodes within the GKE cluster.
 func createGKENodePool(
 	ctx *pulumi.Context,
@@ -104,31 +118,52 @@ func createGKENodePool(
 
 	for key, nodePool := range ClusterConfig.NodePools {
 		resourceName := ""
-		if key == "base" {
+		if key == baseNodePoolKey {
 			resourceName = fmt.Sprintf("%s-gke-%s-np", projectConfig.ResourceNamePrefix, cloudRegion.Region)
 		} else {
 			resourceName = fmt.Sprintf("%s-gke-%s-%s-np", projectConfig.ResourceNamePrefix, cloudRegion.Region, nodePool.KeyName)
 		}
 
+		// Without locations the pool spans the zones of the cluster.
+		var nodeLocations pulumi.StringArrayInput
+		if len(nodePool.Locations) > 0 {
+			nodeLocations = pulumi.ToStringArray(nodePool.Locations)
+		}
+		var imageType pulumi.StringPtrInput
+		if nodePool.ImageType != "" {
+			imageType = pulumi.String(nodePool.ImageType)
+		}
+		var localSsdConfig container.NodePoolNodeConfigEphemeralStorageLocalSsdConfigPtrInput
+		if nodePool.LocalSsdCount > 0 {
+			localSsdConfig = &container.NodePoolNodeConfigEphemeralStorageLocalSsdConfigArgs{
+				LocalSsdCount: pulumi.Int(nodePool.LocalSsdCount),
+			}
+		}
+
 		// Create the node pool using the provided configuration.
 		np, err := container.NewNodePool(ctx, resourceName, &container.NodePoolArgs{
 			Cluster:          clusterID,
 			Name:             pulumi.String(resourceName),
 			InitialNodeCount: pulumi.Int(nodePool.InitialNodeCount),
+			NodeLocations:    nodeLocations,
 			NodeConfig: &container.NodePoolNodeConfigArgs{
-				Metadata:       nodePool.Metadata,
-				Preemptible:    pulumi.Bool(nodePool.Preemptible),
-				MachineType:    pulumi.String(nodePool.MachineType),
-				OauthScopes:    nodePool.OauthScopes,
-				Labels:         nodePool.Labels, // Comma added here
-				DiskType:       pulumi.String(nodePool.DiskType),
-				DiskSizeGb:     pulumi.Int(nodePool.DiskSizeGb),
-				ServiceAccount: serviceAccount["admin"].ServiceAccount.Email,
+				Metadata: pulumi.StringMap{
+					"disable-legacy-endpoints": pulumi.String("true"),
+				},
+				Preemptible:                    pulumi.Bool(nodePool.Preemptible),
+				Spot:                           pulumi.Bool(nodePool.Spot),
+				MachineType:                    pulumi.String(nodePool.MachineType),
+				ImageType:                      imageType,
+				Labels:                         pulumi.ToStringMap(nodePool.Labels),
+				DiskType:                       pulumi.String(nodePool.DiskType),
+				DiskSizeGb:                     pulumi.Int(nodePool.DiskSizeGb),
+				EphemeralStorageLocalSsdConfig: localSsdConfig,
+				ServiceAccount:                 serviceAccount["admin"].ServiceAccount.Email,
 				ResourceLabels: mergeStringMaps(
 					pulumi.StringMap{
-						"goog-gke-node-pool-provisioning-model": pulumi.String("on-demand"),
+						"goog-gke-node-pool-provisioning-model": pulumi.String(provisioningModel(nodePool)),
 					},
-					nodePool.ResourceLabels,
+					pulumi.ToStringMap(nodePool.ResourceLabels),
 				),
 				KubeletConfig: &container.NodePoolNodeConfigKubeletConfigArgs{
 					CpuCfsQuota:       pulumi.Bool(false),
@@ -136,7 +171,11 @@ func createGKENodePool(
 					CpuManagerPolicy:  pulumi.String(""),
 					PodPidsLimit:      pulumi.Int(1024),
 				},
-				WorkloadMetadataConfig: nodePool.WorkloadMetadataConfig,
+				WorkloadMetadataConfig: &container.NodePoolNodeConfigWorkloadMetadataConfigArgs{
+					Mode: pulumi.String("GKE_METADATA"),
+				},
+				Taints:            nodeTaints(nodePool.Taints),
+				GuestAccelerators: guestAccelerators(nodePool.GPU),
 			},
 			Autoscaling: &container.NodePoolAutoscalingArgs{
 				LocationPolicy: pulumi.String(nodePool.LocationPolicy),
@@ -147,6 +186,7 @@ func createGKENodePool(
 				AutoRepair:  pulumi.Bool(ClusterConfig.Management.AutoRepair),
 				AutoUpgrade: pulumi.Bool(ClusterConfig.Management.AutoUpgrade),
 			},
+			UpgradeSettings: upgradeSettings(nodePool.UpgradeSettings),
 		}, pulumi.DependsOn([]pulumi.Resource{serviceAccount["admin"].ServiceAccount}))
 
 		if err != nil {
@@ -163,17 +203,19 @@ func createGKENodePool(
 // createKubernetesProvider creates a Kubernetes provider using the kubeconfig generated from the GKE cluster's endpoint and authentication credentials.
 // The provider is used to manage Kubernetes resources on the created cluster and interacts with the cluster based on its API server details.
 // This function is essential for using Pulumi to deploy Kubernetes resources onto the GKE cluster.
+// The provider depends on the node pools, so every Kubernetes resource waits for nodes to schedule on.
 func createKubernetesProvider(
 	ctx *pulumi.Context,
 	clusterName string,
 	gkeCluster *container.Cluster,
+	nodePools []pulumi.Resource,
 ) (*kubernetes.Provider, error) {
 
 	resourceName := fmt.Sprintf("%s-kubeconfig", clusterName)
 
 	return kubernetes.NewProvider(ctx, resourceName, &kubernetes.ProviderArgs{
 		Kubeconfig: generateKubeconfig(gkeCluster.Endpoint, gkeCluster.Name, gkeCluster.MasterAuth),
-	}, pulumi.DependsOn([]pulumi.Resource{gkeCluster}))
+	}, pulumi.DependsOn(append([]pulumi.Resource{gkeCluster}, nodePools...)))
 }
 
 // generateKubeconfig generates a kubeconfig formatted string based on the GKE cluster's endpoint, cluster name, and master authentication credentials.
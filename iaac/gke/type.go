@@ -1,27 +1,51 @@
 package gke
 
-import (
-	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/container"
-	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
-)
-
-// NodePoolConfig holds the configuration for a GKE node pool
+// NodePoolConfig holds the configuration of a GKE node pool, an entry of `gke:nodePools`.
 type NodePoolConfig struct {
-	KeyName                string
-	MachineType            string
-	DiskSizeGb             int
-	DiskType               string
-	Preemptible            bool
-	InitialNodeCount       int
-	MinMasterVersion       pulumi.String
-	MinNodeCount           int
-	MaxNodeCount           int
-	OauthScopes            pulumi.StringArray
-	WorkloadMetadataConfig *container.NodePoolNodeConfigWorkloadMetadataConfigArgs
-	Metadata               pulumi.StringMap
-	ResourceLabels         pulumi.StringMap
-	Labels                 pulumi.StringMap
-	LocationPolicy         string
+	KeyName          string            `json:"-"`
+	MachineType      string            `json:"machineType,omitempty"`
+	DiskSizeGb       int               `json:"diskSizeGb,omitempty"`
+	DiskType         string            `json:"diskType,omitempty"`
+	ImageType        string            `json:"imageType,omitempty"`     // e.g. `COS_CONTAINERD`; defaults to the GKE default
+	LocalSsdCount    int               `json:"localSsdCount,omitempty"` // local SSDs backing the ephemeral storage of the Pods
+	Spot             bool              `json:"spot,omitempty"`
+	Preemptible      bool              `json:"preemptible,omitempty"`
+	InitialNodeCount int               `json:"initialNodeCount,omitempty"` // defaults to the minimum count
+	MinNodeCount     int               `json:"minNodeCount,omitempty"`
+	MaxNodeCount     int               `json:"maxNodeCount,omitempty"`
+	LocationPolicy   string            `json:"locationPolicy,omitempty"` // `BALANCED` or `ANY`
+	Locations        []string          `json:"locations,omitempty"`      // zones of the pool; defaults to the zones of the cluster
+	Labels           map[string]string `json:"labels,omitempty"`
+	ResourceLabels   map[string]string `json:"resourceLabels,omitempty"`
+	Taints           []NodePoolTaint   `json:"taints,omitempty"`
+	UpgradeSettings  *UpgradeSettings  `json:"upgradeSettings,omitempty"`
+	GPU              *GPUConfig        `json:"gpu,omitempty"`
+}
+
+// NodePoolTaint is a Kubernetes taint set on every node of a pool.
+type NodePoolTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"` // `NoSchedule`, `PreferNoSchedule` or `NoExecute`
+}
+
+// UpgradeSettings holds how the nodes of a pool are replaced on upgrades.
+type UpgradeSettings struct {
+	Strategy       string `json:"strategy,omitempty"`       // `surge` ( default ) or `blue-green`
+	MaxSurge       int    `json:"maxSurge,omitempty"`       // `surge`: extra nodes created during the upgrade
+	MaxUnavailable int    `json:"maxUnavailable,omitempty"` // `surge`: nodes upgraded at once without a replacement
+	BatchNodeCount int    `json:"batchNodeCount,omitempty"` // `blue-green`: nodes drained per batch
+	SoakDuration   string `json:"soakDuration,omitempty"`   // `blue-green`: wait before the old nodes are deleted, e.g. `600s`
+}
+
+// GPUConfig holds the accelerators attached to every node of a GPU node pool.
+type GPUConfig struct {
+	Type             string `json:"type"`                       // accelerator type, e.g. `nvidia-l4`
+	Count            int    `json:"count,omitempty"`            // accelerators per node
+	Sharing          string `json:"sharing,omitempty"`          // `time-sharing` or `mig`; empty dedicates each GPU to a Pod
+	MaxSharedClients int    `json:"maxSharedClients,omitempty"` // Pods sharing a GPU with `time-sharing`
+	PartitionSize    string `json:"partitionSize,omitempty"`    // MIG partition of each GPU, e.g. `1g.5gb`
+	DriverVersion    string `json:"driverVersion,omitempty"`    // `default`, `latest` or `disabled` to install the drivers yourself
 }
 
 type NodePoolConfigs = map[string]NodePoolConfig
@@ -1,6 +1,19 @@
 package gke
 
-import "github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+import (
+	"fmt"
+	"mlops/global"
+
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/container"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// NodeServiceAccountEmail returns the email of the Service Account the node pools run as,
+// which is the identity the kubelet uses to pull images.
+func NodeServiceAccountEmail(projectConfig global.ProjectConfig) string {
+	admin := AdministrationIAM["admin"]
+	return fmt.Sprintf("%s-admin@%s.iam.gserviceaccount.com", admin.ResourceNamePrefix, projectConfig.ProjectId)
+}
 
 func mergeStringMaps(a, b pulumi.StringMap) pulumi.StringMap {
 	result := pulumi.StringMap{}
@@ -13,16 +26,78 @@ func mergeStringMaps(a, b pulumi.StringMap) pulumi.StringMap {
 	return result
 }
 
-// mergeNodePoolConfigs merges two maps of NodePoolConfig.
-func mergeNodePoolConfigs(a, b NodePoolConfigs) NodePoolConfigs {
-	merged := make(NodePoolConfigs)
-	// Add all entries from a.
-	for k, v := range a {
-		merged[k] = v
+// provisioningModel returns how the nodes of a pool are provisioned, recorded as a resource label.
+func provisioningModel(nodePool NodePoolConfig) string {
+	if nodePool.Spot {
+		return "spot"
 	}
-	// Add all entries from b (overriding duplicates).
-	for k, v := range b {
-		merged[k] = v
+	if nodePool.Preemptible {
+		return "preemptible"
+	}
+	return "on-demand"
+}
+
+// nodeTaints converts the taints of a node pool to the GKE API effects.
+func nodeTaints(taints []NodePoolTaint) container.NodePoolNodeConfigTaintArray {
+	var nodeTaints container.NodePoolNodeConfigTaintArray
+	for _, taint := range taints {
+		nodeTaints = append(nodeTaints, &container.NodePoolNodeConfigTaintArgs{
+			Key:    pulumi.String(taint.Key),
+			Value:  pulumi.String(taint.Value),
+			Effect: pulumi.String(nodePoolTaintEffects[taint.Effect]),
+		})
+	}
+	return nodeTaints
+}
+
+// upgradeSettings returns the surge or blue-green upgrade settings of a node pool; nil keeps the GKE defaults.
+func upgradeSettings(upgrade *UpgradeSettings) *container.NodePoolUpgradeSettingsArgs {
+	if upgrade == nil {
+		return nil
+	}
+	if upgrade.Strategy == upgradeStrategyBlueGreen {
+		rolloutPolicy := &container.NodePoolUpgradeSettingsBlueGreenSettingsStandardRolloutPolicyArgs{}
+		if upgrade.BatchNodeCount > 0 {
+			rolloutPolicy.BatchNodeCount = pulumi.Int(upgrade.BatchNodeCount)
+		}
+		blueGreen := &container.NodePoolUpgradeSettingsBlueGreenSettingsArgs{
+			StandardRolloutPolicy: rolloutPolicy,
+		}
+		if upgrade.SoakDuration != "" {
+			blueGreen.NodePoolSoakDuration = pulumi.String(upgrade.SoakDuration)
+		}
+		return &container.NodePoolUpgradeSettingsArgs{
+			Strategy:          pulumi.String("BLUE_GREEN"),
+			BlueGreenSettings: blueGreen,
+		}
+	}
+	return &container.NodePoolUpgradeSettingsArgs{
+		Strategy:       pulumi.String("SURGE"),
+		MaxSurge:       pulumi.Int(upgrade.MaxSurge),
+		MaxUnavailable: pulumi.Int(upgrade.MaxUnavailable),
+	}
+}
+
+// guestAccelerators returns the accelerators of a GPU node pool, with its sharing strategy and driver installation.
+func guestAccelerators(gpu *GPUConfig) container.NodePoolNodeConfigGuestAcceleratorArray {
+	if gpu == nil {
+		return nil
+	}
+	accelerator := &container.NodePoolNodeConfigGuestAcceleratorArgs{
+		Type:  pulumi.String(gpu.Type),
+		Count: pulumi.Int(gpu.Count),
+		GpuDriverInstallationConfig: &container.NodePoolNodeConfigGuestAcceleratorGpuDriverInstallationConfigArgs{
+			GpuDriverVersion: pulumi.String(gpuDriverVersions[gpu.DriverVersion]),
+		},
+	}
+	switch gpu.Sharing {
+	case gpuSharingTimeSharing:
+		accelerator.GpuSharingConfig = &container.NodePoolNodeConfigGuestAcceleratorGpuSharingConfigArgs{
+			GpuSharingStrategy:     pulumi.String("TIME_SHARING"),
+			MaxSharedClientsPerGpu: pulumi.Int(gpu.MaxSharedClients),
+		}
+	case gpuSharingMIG:
+		accelerator.GpuPartitionSize = pulumi.String(gpu.PartitionSize)
 	}
-	return merged
+	return container.NodePoolNodeConfigGuestAcceleratorArray{accelerator}
 }
@@ -1,11 +1,14 @@
 package gke
 
 import (
-	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"mlops/iam"
+	"regexp"
 )
 
 var (
+	// Subsystem reported by the errors of the cluster resources.
+	subsystem = "gke"
+
 	GKEDefaultVersion = "1.31.5-gke.1169000"
 
 	AdministrationIAM = map[string]iam.IAM{
@@ -26,35 +29,94 @@ var (
 		},
 	}
 
-	nodePoolsConfig = NodePoolConfigs{
-		"highmem": NodePoolConfig{
-			MachineType:      "e2-highmem-4",
+	// Defaults of the `gke:nodePools` fields left unset.
+	nodePoolDefaultMachineType    = "e2-standard-4"
+	nodePoolDefaultDiskSizeGb     = 100
+	nodePoolDefaultDiskType       = "pd-standard"
+	nodePoolDefaultMaxNodeCount   = 5
+	nodePoolDefaultLocationPolicy = "BALANCED"
+	// The Kubernetes provider and the NEGs of the load balancer follow the base pool, so it must exist.
+	baseNodePoolKey = "base"
+
+	// Allowed values of the `gke:nodePools` fields.
+	nodePoolDiskTypes        = []string{"pd-standard", "pd-balanced", "pd-ssd", "hyperdisk-balanced"}
+	nodePoolImageTypes       = []string{"COS_CONTAINERD", "UBUNTU_CONTAINERD"}
+	nodePoolLocationPolicies = []string{"BALANCED", "ANY"}
+	// Kubernetes taint effects, mapped to the GKE API values.
+	nodePoolTaintEffects = map[string]string{
+		"NoSchedule":       "NO_SCHEDULE",
+		"PreferNoSchedule": "PREFER_NO_SCHEDULE",
+		"NoExecute":        "NO_EXECUTE",
+	}
+	upgradeStrategySurge     = "surge"
+	upgradeStrategyBlueGreen = "blue-green"
+
+	nodePoolNameRegex = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+	machineTypeRegex  = regexp.MustCompile(`^[a-z0-9]+-[a-z0-9-]+$`)
+	labelKeyRegex     = regexp.MustCompile(`^([a-z0-9]([-a-z0-9.]*[a-z0-9])?/)?[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+	labelValueRegex   = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?)?$`)
+	soakDurationRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?s$`)
+
+	// `gke:nodePoolMachineType`, ... configured the base pool before `gke:nodePools`.
+	// They are still mapped onto the base pool of the stacks that do not set `gke:nodePools`.
+	legacyNodePoolKeys = []string{
+		"gke:nodePoolMachineType",
+		"gke:nodePoolDiskSizeGb",
+		"gke:nodePoolDiskType",
+		"gke:nodePoolMaxNodeCount",
+		"gke:nodePoolPreemptible",
+	}
+
+	// GPU node pool defaults; the nodes are tainted so only Pods requesting GPUs land on them.
+	gpuDefaultMachineType   = "g2-standard-8"
+	gpuDefaultCount         = 1
+	gpuDefaultSharedClients = 2
+	gpuDefaultDriverVersion = "default"
+	gpuTaintKey             = "nvidia.com/gpu"
+	// GPU sharing strategies and driver versions, mapped to the GKE API values.
+	gpuSharingTimeSharing = "time-sharing"
+	gpuSharingMIG         = "mig"
+	gpuDriverVersions     = map[string]string{
+		"default":  "DEFAULT",
+		"latest":   "LATEST",
+		"disabled": "INSTALLATION_DISABLED",
+	}
+
+	// Node pools of the stacks that do not set `gke:nodePools`.
+	defaultNodePools = NodePoolConfigs{
+		"base": NodePoolConfig{
+			MachineType:      "e2-standard-4",
 			DiskSizeGb:       100,
-			InitialNodeCount: 0,
-			MinNodeCount:     0,
+			DiskType:         "pd-standard",
+			InitialNodeCount: 1,
+			MinNodeCount:     3,
 			MaxNodeCount:     5,
-			Preemptible:      false,
-			LocationPolicy:   "ANY",
-			ResourceLabels: pulumi.StringMap{
-				"type-dedicated": pulumi.String("memory-optimized"),
+			LocationPolicy:   "BALANCED",
+		},
+		"highmem": NodePoolConfig{
+			MachineType:    "e2-highmem-4",
+			DiskSizeGb:     100,
+			MinNodeCount:   0,
+			MaxNodeCount:   5,
+			LocationPolicy: "ANY",
+			ResourceLabels: map[string]string{
+				"type-dedicated": "memory-optimized",
 			},
-			Labels: pulumi.StringMap{
-				"dedicated": pulumi.String("highmem"),
+			Labels: map[string]string{
+				"dedicated": "highmem",
 			},
 		},
 		"highcpu": NodePoolConfig{
-			MachineType:      "e2-standard-16",
-			DiskSizeGb:       100,
-			InitialNodeCount: 0,
-			MinNodeCount:     0,
-			MaxNodeCount:     5,
-			Preemptible:      false,
-			LocationPolicy:   "ANY",
-			ResourceLabels: pulumi.StringMap{
-				"type-dedicated": pulumi.String("cpu-optimized"),
+			MachineType:    "e2-standard-16",
+			DiskSizeGb:     100,
+			MinNodeCount:   0,
+			MaxNodeCount:   5,
+			LocationPolicy: "ANY",
+			ResourceLabels: map[string]string{
+				"type-dedicated": "cpu-optimized",
 			},
-			Labels: pulumi.StringMap{
-				"dedicated": pulumi.String("highcpu"),
+			Labels: map[string]string{
+				"dedicated": "highcpu",
 			},
 		},
 	}
@@ -2,6 +2,7 @@ package global
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -12,7 +13,7 @@ import (
 
 func GenerateProjectConfig(
 	ctx *pulumi.Context,
-) ProjectConfig {
+) (ProjectConfig, error) {
 
 	domain := config.Get(ctx, "project:domain")
 	whitelistedIPs := config.Get(ctx, "project:whitelistedIPs")
@@ -22,6 +23,37 @@ func GenerateProjectConfig(
 
 	ValidateMLOpsTarget(ctx)
 	ValidateConfig(ctx)
+	if err := configureValuesDir(ctx); err != nil {
+		return ProjectConfig{}, err
+	}
+	dns, err := configureDNS(ctx, domain)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+	certificates, err := configureCertificates(ctx, dns)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+	stage, err := configureStage(ctx)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+	gkeMode, err := configureGKEMode(ctx)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+	oauth2Proxy, err := configureOAuth2Proxy(ctx, domain)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+	iap, err := configureIAP(ctx)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+	loadBalancer, err := configureLoadBalancer(ctx, domain)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
 	return ProjectConfig{
 		ResourceNamePrefix: configureResourcePrefix(ctx),
 		ProjectId:          configureProjectId(ctx),
@@ -30,7 +62,313 @@ func GenerateProjectConfig(
 		EnabledRegion:      configureRegion(ctx),
 		CloudSQL:           getCloudSQLConfig(ctx),
 		WhitelistedIPs:     whitelistedIPs,
+		Addons:             configureAddons(ctx),
+		ArtifactRegistry:   configureRegistryAuth(ctx),
+		Stage:              stage,
+		GKEMode:            gkeMode,
+		AutopilotGPU:       configureAutopilotGPU(ctx),
+		OAuth2Proxy:        oauth2Proxy,
+		IAP:                iap,
+		DNS:                dns,
+		Certificates:       certificates,
+		LoadBalancer:       loadBalancer,
+	}, nil
+}
+
+// configureLoadBalancer reads whether the Global Load Balancer ( `vpc:loadBalancer` ) routes to the nginx
+// ingress controller, and whether its NEGs are attached by the AutoNEG controller ( `vpc.autoNEG` ).
+func configureLoadBalancer(
+	ctx *pulumi.Context,
+	domain string,
+) (LoadBalancerConfig, error) {
+
+	loadBalancer := LoadBalancerConfig{
+		Enabled: config.GetBool(ctx, "vpc:loadBalancer"),
+		AutoNEG: config.GetBool(ctx, "vpc.autoNEG"),
+	}
+	cloudArmor, err := configureCloudArmor(ctx, loadBalancer.Enabled)
+	if err != nil {
+		return loadBalancer, err
+	}
+	loadBalancer.CloudArmor = cloudArmor
+	if !loadBalancer.Enabled {
+		return loadBalancer, nil
+	}
+	if domain == "" {
+		ctx.Log.Warn("The Global Load Balancer routes by hostname; without `project:domain` every request goes to the nginx default backend.", nil)
+	}
+	fmt.Printf("\033[1;32m[INFO] The Global Load Balancer routes the tool hostnames to the nginx ingress controller NEGs.\n\033[0m")
+	return loadBalancer, nil
+}
+
+// configureCertificates reads the Let's Encrypt endpoint ( `certManager:acme` ) and the challenge solver
+// ( `certManager:solver` ); DNS-01 needs the Cloud DNS zone of the hostnames.
+func configureCertificates(
+	ctx *pulumi.Context,
+	dns DNSConfig,
+) (CertificatesConfig, error) {
+
+	certificates := CertificatesConfig{
+		ACME: config.Get(ctx, "certManager:acme"),
+	}
+	switch certificates.ACME {
+	case "":
+		certificates.ACME = ACMEProduction
+	case ACMEProduction:
+	case ACMEStaging:
+		ctx.Log.Warn("Certificates are issued by the Let's Encrypt staging endpoint; browsers will not trust them.", nil)
+	default:
+		return certificates, fmt.Errorf("Let's Encrypt endpoint '%s' is not supported; use `%s` or `%s`", certificates.ACME, ACMEProduction, ACMEStaging)
+	}
+
+	switch solver := config.Get(ctx, "certManager:solver"); solver {
+	case "", CertSolverHTTP01:
+	case CertSolverDNS01:
+		if !dns.Enabled {
+			return certificates, fmt.Errorf("the `%s` solver needs the Cloud DNS zone of the domain; set `dns:createZone` or `dns:managedZone`", CertSolverDNS01)
+		}
+		certificates.Wildcard = true
+	default:
+		return certificates, fmt.Errorf("certificate solver '%s' is not supported; use `%s` or `%s`", solver, CertSolverHTTP01, CertSolverDNS01)
+	}
+	if certificates.Wildcard {
+		fmt.Printf("\033[1;32m[INFO] A wildcard certificate is issued for the domain through DNS-01; every tool ingress uses it.\n\033[0m")
+	}
+	return certificates, nil
+}
+
+// configureCloudArmor reads the `cloudArmor:*` configuration of the security policy of the Global Load Balancer.
+func configureCloudArmor(
+	ctx *pulumi.Context,
+	loadBalancer bool,
+) (CloudArmorConfig, error) {
+
+	cloudArmor := CloudArmorConfig{
+		Enabled:        config.GetBool(ctx, "cloudArmor:enabled"),
+		WAFRules:       CloudArmorDefaultWAFRules,
+		WAFSensitivity: config.GetInt(ctx, "cloudArmor:wafSensitivity"),
+		RateLimit:      config.GetInt(ctx, "cloudArmor:rateLimit"),
+	}
+	if !cloudArmor.Enabled {
+		return cloudArmor, nil
+	}
+	if !loadBalancer {
+		ctx.Log.Warn("`cloudArmor:enabled` only protects the Global Load Balancer; enable `vpc:loadBalancer` too.", nil)
+	}
+	switch wafRules := config.Get(ctx, "cloudArmor:wafRules"); wafRules {
+	case "":
+	case "none":
+		cloudArmor.WAFRules = nil
+	default:
+		cloudArmor.WAFRules = nil
+		for _, rule := range FormatStringIntoList(wafRules) {
+			if !wafRuleRegex.MatchString(rule) {
+				return cloudArmor, fmt.Errorf("Cloud Armor WAF rule '%s' is not a preconfigured rule name, e.g. `sqli-v33-stable`", rule)
+			}
+			cloudArmor.WAFRules = append(cloudArmor.WAFRules, rule)
+		}
 	}
+	if cloudArmor.WAFSensitivity == 0 {
+		cloudArmor.WAFSensitivity = 1
+	}
+	if cloudArmor.WAFSensitivity < 1 || cloudArmor.WAFSensitivity > 4 {
+		return cloudArmor, fmt.Errorf("Cloud Armor WAF sensitivity %d is not supported; use 1 to 4", cloudArmor.WAFSensitivity)
+	}
+	if cloudArmor.RateLimit < 0 {
+		return cloudArmor, fmt.Errorf("`cloudArmor:rateLimit` must be a number of requests per minute, or 0 to disable it")
+	}
+	if regions := config.Get(ctx, "cloudArmor:allowedRegions"); regions != "" {
+		for _, region := range FormatStringIntoList(regions) {
+			region = strings.ToUpper(region)
+			if !regionCodeRegex.MatchString(region) {
+				return cloudArmor, fmt.Errorf("Cloud Armor region '%s' must be a two-letter country code, e.g. `FR`", region)
+			}
+			cloudArmor.AllowedRegions = append(cloudArmor.AllowedRegions, region)
+		}
+	}
+	fmt.Printf("\033[1;32m[INFO] Cloud Armor protects the Global Load Balancer; WAF rules: %s.\n\033[0m", formatListIntoString(cloudArmor.WAFRules))
+	return cloudArmor, nil
+}
+
+// configureDNS reads the Cloud DNS zone ( `dns:createZone` or `dns:managedZone` ) the hostnames are recorded in.
+func configureDNS(
+	ctx *pulumi.Context,
+	domain string,
+) (DNSConfig, error) {
+
+	dnsConfig := DNSConfig{
+		CreateZone:  config.GetBool(ctx, "dns:createZone"),
+		ManagedZone: config.Get(ctx, "dns:managedZone"),
+	}
+	dnsConfig.Enabled = dnsConfig.CreateZone || dnsConfig.ManagedZone != ""
+	if !dnsConfig.Enabled {
+		return dnsConfig, nil
+	}
+	if dnsConfig.CreateZone && dnsConfig.ManagedZone != "" {
+		return dnsConfig, fmt.Errorf("set either `dns:createZone` or `dns:managedZone`, not both")
+	}
+	if domain == "" {
+		return dnsConfig, fmt.Errorf("DNS records need `project:domain`")
+	}
+	fmt.Printf("\033[1;32m[INFO] DNS records of the '%s' hostnames are managed in Cloud DNS.\n\033[0m", domain)
+	return dnsConfig, nil
+}
+
+// configureIAP reads the `iap:*` configuration of the Identity-Aware Proxy on the Global Load Balancer.
+func configureIAP(
+	ctx *pulumi.Context,
+) (IAPConfig, error) {
+
+	iapConfig := IAPConfig{
+		Enabled: config.GetBool(ctx, "iap:enabled"),
+	}
+	if !iapConfig.Enabled {
+		return iapConfig, nil
+	}
+	if !config.GetBool(ctx, "vpc:loadBalancer") {
+		ctx.Log.Warn("`iap:enabled` only protects the Global Load Balancer; enable `vpc:loadBalancer` too.", nil)
+	}
+	iapConfig.Brand = config.Get(ctx, "iap:brand")
+	iapConfig.SupportEmail = config.Get(ctx, "iap:supportEmail")
+	if iapConfig.SupportEmail == "" {
+		iapConfig.SupportEmail = config.Get(ctx, "project:email")
+	}
+	if iapConfig.Brand == "" && iapConfig.SupportEmail == "" {
+		return iapConfig, fmt.Errorf("IAP needs an OAuth brand; set `iap:brand` to an existing one or `iap:supportEmail` to create it")
+	}
+	if members := config.Get(ctx, "iap:members"); members != "" {
+		for _, member := range FormatStringIntoList(members) {
+			if !strings.Contains(member, ":") {
+				return iapConfig, fmt.Errorf("IAP member '%s' must be prefixed with its type, e.g. `user:` or `group:`", member)
+			}
+			iapConfig.Members = append(iapConfig.Members, member)
+		}
+	}
+	if len(iapConfig.Members) == 0 {
+		ctx.Log.Warn("IAP is enabled without `iap:members`; nobody can reach the Global Load Balancer.", nil)
+	}
+	fmt.Printf("\033[1;32m[INFO] Identity-Aware Proxy protects the Global Load Balancer; %d member(s) granted access.\n\033[0m", len(iapConfig.Members))
+	return iapConfig, nil
+}
+
+// configureOAuth2Proxy reads the `oauth2proxy:*` configuration of the Google login in front of the tool ingresses.
+func configureOAuth2Proxy(
+	ctx *pulumi.Context,
+	domain string,
+) (OAuth2ProxyConfig, error) {
+
+	proxyConfig := OAuth2ProxyConfig{
+		Enabled: config.GetBool(ctx, "oauth2proxy:enabled"),
+	}
+	if !proxyConfig.Enabled {
+		return proxyConfig, nil
+	}
+	proxyConfig.ClientId = config.Get(ctx, "oauth2proxy:clientId")
+	proxyConfig.ClientSecret = config.GetSecret(ctx, "oauth2proxy:clientSecret")
+	proxyConfig.AdminEmail = config.Get(ctx, "oauth2proxy:adminEmail")
+	if domains := config.Get(ctx, "oauth2proxy:allowedDomains"); domains != "" {
+		proxyConfig.AllowedDomains = FormatStringIntoList(domains)
+	} else {
+		proxyConfig.AllowedDomains = []string{domain}
+	}
+	if groups := config.Get(ctx, "oauth2proxy:allowedGroups"); groups != "" {
+		proxyConfig.AllowedGroups = FormatStringIntoList(groups)
+	}
+
+	if domain == "" {
+		return proxyConfig, fmt.Errorf("oauth2-proxy needs `project:domain`; its cookie and login host are set on the domain")
+	}
+	if proxyConfig.ClientId == "" {
+		return proxyConfig, fmt.Errorf("oauth2-proxy needs a Google OAuth client; set `oauth2proxy:clientId` and `oauth2proxy:clientSecret`")
+	}
+	if len(proxyConfig.AllowedGroups) > 0 && proxyConfig.AdminEmail == "" {
+		return proxyConfig, fmt.Errorf("`oauth2proxy:allowedGroups` needs `oauth2proxy:adminEmail`, a Workspace admin the group lookups are made as")
+	}
+	fmt.Printf("\033[1;32m[INFO] Tool ingresses require a Google login through oauth2-proxy; allowed domains: %s\n\033[0m", formatListIntoString(proxyConfig.AllowedDomains))
+	return proxyConfig, nil
+}
+
+// configureStage reads the last deployment stage ( `project:stage` ), defaulting to the full deployment.
+func configureStage(
+	ctx *pulumi.Context,
+) (string, error) {
+
+	stage := config.Get(ctx, "project:stage")
+	if stage == "" {
+		return StageTool, nil
+	}
+	if !listContains(DeploymentStages, stage) {
+		return "", fmt.Errorf("stage '%s' is not supported; use one of: %s", stage, formatListIntoString(DeploymentStages))
+	}
+	if stage != StageTool {
+		fmt.Printf("\033[1;32m[INFO] Deployment stops after the '%s' stage.\n\033[0m", stage)
+	}
+	return stage, nil
+}
+
+// StageEnabled reports whether the given stage is deployed, i.e. it is not after `project:stage`.
+func (projectConfig ProjectConfig) StageEnabled(
+	stage string,
+) bool {
+
+	for _, deployed := range DeploymentStages {
+		if deployed == stage {
+			return true
+		}
+		if deployed == projectConfig.Stage {
+			return false
+		}
+	}
+	return false
+}
+
+// configureGKEMode reads whether the cluster runs node pools or is an Autopilot cluster ( `gke:mode` ).
+func configureGKEMode(
+	ctx *pulumi.Context,
+) (string, error) {
+
+	mode := config.Get(ctx, "gke:mode")
+	if mode == "" {
+		return GKEModeStandard, nil
+	}
+	if mode != GKEModeStandard && mode != GKEModeAutopilot {
+		return "", fmt.Errorf("GKE mode '%s' is not supported; use `%s` or `%s`", mode, GKEModeStandard, GKEModeAutopilot)
+	}
+	if mode == GKEModeAutopilot {
+		fmt.Printf("\033[1;32m[INFO] GKE Autopilot provisions the nodes; the node pools of the stack are not created.\n\033[0m")
+	}
+	return mode, nil
+}
+
+// configureAutopilotGPU reads the GPU type Autopilot provisions for the GPU workloads ( `gke:autopilotAccelerator` ).
+func configureAutopilotGPU(
+	ctx *pulumi.Context,
+) string {
+
+	accelerator := config.Get(ctx, "gke:autopilotAccelerator")
+	if accelerator == "" {
+		return AutopilotDefaultAccelerator
+	}
+	return accelerator
+}
+
+// NodeSelector returns the node selector of a dedicated workload ( `highmem`, `highcpu` or `gpu` ): the `dedicated`
+// label of its node pool, or the compute class GKE provisions the nodes for on Autopilot.
+func (projectConfig ProjectConfig) NodeSelector(
+	workload string,
+) map[string]interface{} {
+
+	if projectConfig.GKEMode != GKEModeAutopilot {
+		return map[string]interface{}{"dedicated": workload}
+	}
+	nodeSelector := map[string]interface{}{}
+	for key, value := range autopilotNodeSelectors[workload] {
+		nodeSelector[key] = value
+	}
+	if workload == "gpu" {
+		nodeSelector["cloud.google.com/gke-accelerator"] = projectConfig.AutopilotGPU
+	}
+	return nodeSelector
 }
 
 func configureProjectId(
@@ -73,7 +411,9 @@ func configureSSL(
 	// Review Domain & SSL Configuration
 	if domain != "" {
 		fmt.Printf("\033[1;32m[INFO] Domain '%s' has been provided; SSL Certificates will be configured for this domain.\n\033[0m", domain)
-		fmt.Printf("\033[1;32m[INFO] The DNS for the domain: '%s' must be configured to point to the IP Address of the Global Load Balancer.\n\033[0m", domain)
+		if config.Get(ctx, "dns:managedZone") == "" && !config.GetBool(ctx, "dns:createZone") {
+			fmt.Printf("\033[1;32m[INFO] The DNS for the domain: '%s' must be configured to point to the IP Address of the Global Load Balancer.\n\033[0m", domain)
+		}
 		return true
 	} else {
 		ctx.Log.Warn("No Domain has been provided; HTTPS will not be enabled for this deployment.", nil)
@@ -96,6 +436,71 @@ func ConfigureArtifactRegistry(
 	return ArtifactRegistryConfig
 }
 
+// configureRegistryAuth reads how workloads authenticate to Artifact Registry.
+func configureRegistryAuth(
+	ctx *pulumi.Context,
+) ArtifactRegistryConfig {
+
+	registryAuth := ArtifactRegistryConfig{
+		Keyless:    config.GetBool(ctx, "registry:keyless"),
+		BuilderKey: config.GetBool(ctx, "registry:builderKey"),
+	}
+	if registryAuth.Keyless {
+		fmt.Printf("\033[1;32m[INFO] Artifact Registry is keyless; images are pulled with the GKE node Service Account.\n\033[0m")
+	} else if registryAuth.BuilderKey {
+		ctx.Log.Warn("`registry:builderKey` only applies when `registry:keyless` is enabled.", nil)
+	}
+	return registryAuth
+}
+
+// configureValuesDir reads the optional on-disk directory whose values templates replace the embedded ones.
+func configureValuesDir(
+	ctx *pulumi.Context,
+) error {
+
+	dir := config.Get(ctx, "project:valuesDir")
+	if dir == "" {
+		return nil
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("values directory '%s' does not exist", dir)
+	}
+	valuesDir = dir
+	fmt.Printf("\033[1;32m[INFO] Values templates found in '%s' take precedence over the embedded ones.\n\033[0m", dir)
+	return nil
+}
+
+// ConfigureHelmChart applies the `helm:<component>` overrides of the stack configuration to the default chart,
+// so chart versions can be pinned or bumped per environment.
+func ConfigureHelmChart(
+	ctx *pulumi.Context,
+	component string,
+	defaults HelmChartConfig,
+) (HelmChartConfig, error) {
+
+	var overrides HelmChartConfig
+	key := fmt.Sprintf("helm:%s", component)
+	if err := config.GetObject(ctx, key, &overrides); err != nil {
+		return defaults, fmt.Errorf("failed to parse `%s`: %w", key, err)
+	}
+
+	chart := defaults
+	if overrides.Repo != "" {
+		chart.Repo = overrides.Repo
+	}
+	if overrides.Version != "" {
+		chart.Version = overrides.Version
+		fmt.Printf("\033[1;32m[INFO] Helm chart '%s' pinned to version [ %s ]\n\033[0m", chart.Chart, chart.Version)
+	}
+	chart.Values = append(chart.Values, overrides.Values...)
+	for _, overlay := range chart.Values {
+		if !CheckFileExists(overlay) {
+			return chart, fmt.Errorf("values overlay '%s' of `%s` does not exist", overlay, key)
+		}
+	}
+	return chart, nil
+}
+
 // configureRegions separates regions into enabled and not enabled
 func configureRegion(
 	ctx *pulumi.Context,
@@ -156,6 +561,27 @@ func ValidateMLOpsTarget(
 	}
 }
 
+// configureAddons reads the comma separated list of add-ons (`project:addons`) and validates each against the Allowlist.
+func configureAddons(
+	ctx *pulumi.Context,
+) []string {
+
+	var addons []string
+	value := config.Get(ctx, "project:addons")
+	if value == "" {
+		return addons
+	}
+	for _, addon := range FormatStringIntoList(value) {
+		if !listContains(MLOpsAllowedAddons, addon) {
+			ctx.Log.Error(fmt.Sprintf("Add-on '%s' is not included in the Allowlist: %s", addon, formatListIntoString(MLOpsAllowedAddons)), nil)
+			continue
+		}
+		fmt.Printf("\033[1;32m[INFO] Add-on targeted for deployment; %s\n\033[0m", addon)
+		addons = append(addons, addon)
+	}
+	return addons
+}
+
 // ValidateConfig validates configuration values for domain, email, whitelistedIPs, and githubRepo.
 func ValidateConfig(
 	ctx *pulumi.Context,
@@ -175,12 +601,15 @@ func ValidateConfig(
 		}
 	}
 
+	// The allowlist is optional, e.g. when oauth2-proxy authenticates the users instead.
 	whitelistedIPs := config.Get(ctx, "project:whitelistedIPs")
-	ipList := strings.Split(whitelistedIPs, ",")
-	for _, ip := range ipList {
-		trimmed := strings.TrimSpace(ip)
-		if !strings.HasSuffix(trimmed, "/32") {
-			ctx.Log.Error(fmt.Sprintf("Whitelist IP '%s' is not in the correct format. Each IP should end with '/32'", trimmed), nil)
+	if whitelistedIPs != "" {
+		ipList := strings.Split(whitelistedIPs, ",")
+		for _, ip := range ipList {
+			trimmed := strings.TrimSpace(ip)
+			if !strings.HasSuffix(trimmed, "/32") {
+				ctx.Log.Error(fmt.Sprintf("Whitelist IP '%s' is not in the correct format. Each IP should end with '/32'", trimmed), nil)
+			}
 		}
 	}
 
@@ -11,7 +11,7 @@ import (
 func EnableGCPServices(
 	ctx *pulumi.Context,
 	projectConfig ProjectConfig,
-) []pulumi.Resource {
+) ([]pulumi.Resource, error) {
 
 	var gcpDependencies []pulumi.Resource
 
@@ -25,11 +25,10 @@ func EnableGCPServices(
 			Service:                  pulumi.String(service),
 			DisableOnDestroy:         pulumi.Bool(false),
 		})
-
 		if err != nil {
-			ctx.Log.Error(fmt.Sprintf("failed to enable service %s: %s", service, err), nil)
+			return nil, NewResourceError("services", resourceName, err)
 		}
 		gcpDependencies = append(gcpDependencies, gcpService)
 	}
-	return gcpDependencies
+	return gcpDependencies, nil
 }
@@ -1,6 +1,8 @@
 package global
 
 import (
+	"fmt"
+
 	"github.com/pulumi/pulumi-gcp/sdk/v6/go/gcp/container"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
@@ -23,10 +25,67 @@ type ProjectConfig struct {
 	SSL                bool
 	EnabledRegion      CloudRegion
 	Target             string
+	Addons             []string
 	CloudSQL           *CloudSQLConfig
 	Email              string
 	WhitelistedIPs     string
 	ArtifactRegistry   ArtifactRegistryConfig
+	Stage              string // last deployment stage, see DeploymentStages
+	GKEMode            string // `gke:mode`, GKEModeStandard or GKEModeAutopilot
+	AutopilotGPU       string // `gke:autopilotAccelerator`, GPU type of the Autopilot GPU workloads
+	OAuth2Proxy        OAuth2ProxyConfig
+	IAP                IAPConfig
+	DNS                DNSConfig
+	Certificates       CertificatesConfig
+	LoadBalancer       LoadBalancerConfig
+}
+
+// LoadBalancerConfig holds the Global Load Balancer in front of the nginx ingress controller.
+type LoadBalancerConfig struct {
+	Enabled    bool
+	AutoNEG    bool                // the AutoNEG controller attaches the NEGs to the backend service
+	Address    pulumi.StringOutput // reserved global address, set once created; the tool hostnames point at it
+	CloudArmor CloudArmorConfig
+}
+
+// CloudArmorConfig holds the Cloud Armor security policy of the Global Load Balancer backend service.
+type CloudArmorConfig struct {
+	Enabled        bool
+	WAFRules       []string // preconfigured WAF rules, e.g. `sqli-v33-stable`
+	WAFSensitivity int      // sensitivity level of the WAF rules, 1 ( fewest false positives ) to 4
+	RateLimit      int      // requests per minute allowed per client IP; 0 disables throttling
+	AllowedRegions []string // ISO 3166-1 alpha-2 country codes allowed; empty allows all
+}
+
+// CertificatesConfig holds how cert-manager issues the certificates of the tool hostnames.
+type CertificatesConfig struct {
+	ACME     string // Let's Encrypt endpoint, `production` or `staging`
+	Wildcard bool   // `certManager:solver: dns01`, one wildcard certificate for the domain instead of HTTP-01 per host
+}
+
+// DNSConfig holds the Cloud DNS zone the records of the stack hostnames are managed in.
+type DNSConfig struct {
+	Enabled     bool
+	CreateZone  bool   // creates a zone for the domain
+	ManagedZone string // name of an existing zone for the domain
+}
+
+// IAPConfig holds the Identity-Aware Proxy settings of the Global Load Balancer backend service.
+type IAPConfig struct {
+	Enabled      bool
+	Brand        string   // existing OAuth brand, `projects/<number>/brands/<id>`; created when empty
+	SupportEmail string   // support e-mail of a created brand
+	Members      []string // IAM members granted `roles/iap.httpsResourceAccessor`, e.g. `user:`, `group:` or `domain:`
+}
+
+// OAuth2ProxyConfig holds the Google login enforced by oauth2-proxy in front of the tool ingresses.
+type OAuth2ProxyConfig struct {
+	Enabled        bool
+	ClientId       string
+	ClientSecret   pulumi.StringOutput
+	AllowedDomains []string // e-mail domains allowed to log in
+	AllowedGroups  []string // Google groups allowed to log in; checked through the Admin SDK as AdminEmail
+	AdminEmail     string
 }
 
 type CloudRegion struct {
@@ -44,15 +103,41 @@ type CloudSQLConfig struct {
 	User               string `json:"user"`
 	Database           string `json:"database"`
 	InstancePrefixName string
+	DatabaseVersion    string // defaults to POSTGRES_14
 	InstanceName       pulumi.StringOutput
 	Connection         pulumi.StringOutput
 	Password           pulumi.StringOutput
 	DatabaseName       pulumi.StringOutput
 }
 
+// HelmChartConfig holds the coordinates of a Helm chart and the user values overlays applied on top of its base values.
+type HelmChartConfig struct {
+	Chart   string   `json:"-"`
+	Repo    string   `json:"repo"`
+	Version string   `json:"version"`
+	Values  []string `json:"values"` // overlay files, deep-merged in order over the base values
+}
+
 type ArtifactRegistryConfig struct {
 	GithubRepo                                string
 	RegistryName                              string
 	GithubServiceAccountCreate                bool
 	ContinuousDevelopmentServiceAccountCreate bool
+	Keyless                                   bool // pulls use the node identity instead of a JSON key secret
+	BuilderKey                                bool // keeps a key secret for in-cluster builders in keyless mode
+}
+
+// ResourceError reports the subsystem ( vpc, gke, flyte, ... ) and the resource a deployment step failed on.
+type ResourceError struct {
+	Subsystem string
+	Resource  string
+	Err       error
+}
+
+func (e *ResourceError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Subsystem, e.Resource, e.Err)
+}
+
+func (e *ResourceError) Unwrap() error {
+	return e.Err
 }
@@ -2,18 +2,36 @@ package global
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"math/rand"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
+	"mlops/values"
+
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"gopkg.in/yaml.v2"
 )
 
-var logLevel = "INFO" //  TO DO: set a log Level field
+var (
+	logLevel = "INFO" //  TO DO: set a log Level field
+
+	// valuesDir is the on-disk directory whose templates take precedence over the embedded ones ( `project:valuesDir` ).
+	valuesDir = ""
+
+	placeholderRegex = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+	// Cloud Armor preconfigured WAF rule names, e.g. `sqli-v33-stable`, and country codes.
+	wafRuleRegex    = regexp.MustCompile(`^[a-z0-9]+-v[0-9]+-(stable|canary)$`)
+	regionCodeRegex = regexp.MustCompile(`^[A-Z]{2}$`)
+)
 
 // formatListIntoString is a helper function to format the list Items into a string
 func formatListIntoString(values []string) string {
@@ -73,13 +91,99 @@ func normalizeYAML(input interface{}) interface{} {
 	}
 }
 
-// GetValues reads a YAML file from filePath, verifies its existence, substitutes dynamic placeholders,
+// GetValues loads the values template `<tool>/<file>.yaml`, from `project:valuesDir` when present there and from
+// the embedded templates otherwise, deep-merges the overlay files over it, substitutes dynamic placeholders,
 // and returns the resulting data as a pulumi.MapInput.
 func GetValues(
-	filePath string,
+	template string,
 	replacements map[string]interface{},
+	overlays ...string,
 ) (pulumi.MapInput, error) {
 
+	return GetLayeredValues([]string{template}, replacements, overlays...)
+}
+
+// GetLayeredValues deep-merges several values templates in order, e.g. a tool's base template and the template
+// of one of its modes, then applies the overlay files and substitutes the placeholders like GetValues.
+func GetLayeredValues(
+	templates []string,
+	replacements map[string]interface{},
+	overlays ...string,
+) (pulumi.MapInput, error) {
+
+	// Settings the tool does not declare would escape the check of ValidateTemplates.
+	if err := validateSettings(templates, replacements); err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	for _, template := range templates {
+		templateValues, err := readValuesTemplate(template)
+		if err != nil {
+			return nil, err
+		}
+		values = mergeValues(values, templateValues)
+	}
+	return BuildValues(values, replacements, overlays...)
+}
+
+// BuildValues deep-merges the overlay files over the given base values, substitutes dynamic placeholders,
+// and returns the resulting data as a pulumi.MapInput.
+func BuildValues(
+	values map[string]interface{},
+	replacements map[string]interface{},
+	overlays ...string,
+) (pulumi.MapInput, error) {
+
+	for _, overlay := range overlays {
+		overlayValues, err := readValuesFile(overlay)
+		if err != nil {
+			return nil, err
+		}
+		values = mergeValues(values, overlayValues)
+	}
+
+	// Every placeholder must have a setting, else it would reach the chart verbatim.
+	if err := validatePlaceholders(values, replacements); err != nil {
+		return nil, err
+	}
+
+	// Substitute placeholders using the provided replacements.
+	substituted := substitutePlaceholders(values, replacements).(map[string]interface{})
+
+	// DEBUG: Print parsed and substituted YAML map if needed.
+	if logLevel == "DEBUG" {
+		fmt.Println("🔹 Substituted YAML Map:")
+		printPrettyJSON(substituted)
+	}
+
+	// Convert to Pulumi MapInput.
+	pulumiValues := convertToPulumiMap(substituted)
+	return pulumiValues, nil
+}
+
+// readValuesTemplate reads a values template, preferring the on-disk override directory over the embedded copy.
+func readValuesTemplate(
+	template string,
+) (map[string]interface{}, error) {
+
+	if valuesDir != "" {
+		overridePath := filepath.Join(valuesDir, template)
+		if CheckFileExists(overridePath) {
+			return readValuesFile(overridePath)
+		}
+	}
+	data, err := values.Templates.ReadFile(template)
+	if err != nil {
+		return nil, fmt.Errorf("values template %s is not embedded: %w", template, err)
+	}
+	return parseValues(template, data)
+}
+
+// readValuesFile reads and parses a values YAML file into a map with string keys.
+func readValuesFile(
+	filePath string,
+) (map[string]interface{}, error) {
+
 	// Check if the file exists.
 	if !CheckFileExists(filePath) {
 		return nil, fmt.Errorf("file %s does not exist", filePath)
@@ -87,8 +191,16 @@ func GetValues(
 
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read values.yaml: %w", err)
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
 	}
+	return parseValues(filePath, data)
+}
+
+// parseValues parses values YAML content into a map with string keys.
+func parseValues(
+	name string,
+	data []byte,
+) (map[string]interface{}, error) {
 
 	// DEBUG: Print raw YAML content if needed.
 	if logLevel == "DEBUG" {
@@ -97,27 +209,142 @@ func GetValues(
 	}
 
 	// Unmarshal YAML into a generic map.
-	var values interface{}
-	err = yaml.Unmarshal(data, &values)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse values.yaml: %w", err)
+	var parsed interface{}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
 	}
 
 	// Normalize the YAML in case keys are not strings.
-	normalized := normalizeYAML(values).(map[string]interface{})
+	normalized, ok := normalizeYAML(parsed).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must contain a YAML mapping", name)
+	}
+	return normalized, nil
+}
 
-	// Substitute placeholders using the provided replacements.
-	substituted := substitutePlaceholders(normalized, replacements).(map[string]interface{})
+// validatePlaceholders returns an error listing the placeholders of the values that have no setting.
+func validatePlaceholders(
+	values map[string]interface{},
+	replacements map[string]interface{},
+) error {
 
-	// DEBUG: Print parsed and substituted YAML map if needed.
-	if logLevel == "DEBUG" {
-		fmt.Println("🔹 Substituted YAML Map:")
-		printPrettyJSON(substituted)
+	missing := map[string]bool{}
+	collectPlaceholders(values, func(key string) {
+		if _, ok := replacements[key]; !ok {
+			missing[key] = true
+		}
+	})
+	if len(missing) == 0 {
+		return nil
+	}
+	var keys []string
+	for key := range missing {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return fmt.Errorf("values placeholders without a setting: %s", strings.Join(keys, ", "))
+}
 
-	// Convert to Pulumi MapInput.
-	pulumiValues := convertToPulumiMap(substituted)
-	return pulumiValues, nil
+// ValidateTemplates checks every embedded values template, including the overlays of modes a stack does not
+// enable, against the settings its tool declares in ValuesSettings. Overrides from `project:valuesDir` are checked
+// in place of the embedded copies.
+func ValidateTemplates() error {
+
+	var errs []error
+	err := fs.WalkDir(values.Templates, ".", func(template string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		tool := path.Dir(template)
+		settings, ok := ValuesSettings[tool]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: no settings declared for %s", template, tool))
+			return nil
+		}
+		templateValues, err := readValuesTemplate(template)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		replacements := make(map[string]interface{}, len(settings))
+		for _, key := range settings {
+			replacements[key] = nil
+		}
+		if err := validatePlaceholders(templateValues, replacements); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", template, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// validateSettings returns an error listing the settings supplied to a tool's templates that its tool does not
+// declare in ValuesSettings.
+func validateSettings(
+	templates []string,
+	replacements map[string]interface{},
+) error {
+
+	if len(templates) == 0 {
+		return nil
+	}
+	tool := path.Dir(templates[0])
+	declared, ok := ValuesSettings[tool]
+	if !ok {
+		return fmt.Errorf("no settings declared for the %s values templates", tool)
+	}
+	var undeclared []string
+	for key := range replacements {
+		if !listContains(declared, key) {
+			undeclared = append(undeclared, key)
+		}
+	}
+	if len(undeclared) == 0 {
+		return nil
+	}
+	sort.Strings(undeclared)
+	return fmt.Errorf("%s settings not declared in ValuesSettings: %s", tool, strings.Join(undeclared, ", "))
+}
+
+// collectPlaceholders calls visit with the key of every ${key} placeholder found in the values.
+func collectPlaceholders(input interface{}, visit func(string)) {
+	switch v := input.(type) {
+	case string:
+		for _, match := range placeholderRegex.FindAllStringSubmatch(v, -1) {
+			visit(match[1])
+		}
+	case map[string]interface{}:
+		for _, value := range v {
+			collectPlaceholders(value, visit)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectPlaceholders(item, visit)
+		}
+	}
+}
+
+// mergeValues deep-merges overlay into base: nested maps are merged key by key, a null in the overlay
+// removes a key of the base like in Helm, any other value in the overlay replaces the one in the base.
+// A null without a base key is kept, so it still removes the chart default.
+func mergeValues(base, overlay map[string]interface{}) map[string]interface{} {
+	for key, overlayValue := range overlay {
+		if _, inBase := base[key]; inBase && overlayValue == nil {
+			delete(base, key)
+			continue
+		}
+		baseMap, baseIsMap := base[key].(map[string]interface{})
+		overlayMap, overlayIsMap := overlayValue.(map[string]interface{})
+		if baseIsMap && overlayIsMap {
+			base[key] = mergeValues(baseMap, overlayMap)
+			continue
+		}
+		base[key] = overlayValue
+	}
+	return base
 }
 
 // Recursively convert interface{} values to pulumi.Input values
@@ -139,6 +366,9 @@ func pulumiMapConvert(value interface{}) pulumi.Input {
 		return pulumi.Float64(v)
 	case bool:
 		return pulumi.Bool(v)
+	case pulumi.Input:
+		// Outputs, e.g. a reserved address, are resolved by Pulumi.
+		return v
 	default:
 		return pulumi.Any(v)
 	}
@@ -150,17 +380,35 @@ func substitutePlaceholders(input interface{}, replacements map[string]interface
 	switch v := input.(type) {
 	case string:
 		// Use a regex to find all placeholders in the string.
-		re := regexp.MustCompile(`\$\{([^}]+)\}`)
-		return re.ReplaceAllStringFunc(v, func(match string) string {
-			// Extract the key name without the ${ and }.
-			key := strings.TrimSuffix(strings.TrimPrefix(match, "${"), "}")
-			if replacement, ok := replacements[key]; ok {
-				// Convert the replacement value to a string.
-				return fmt.Sprintf("%v", replacement)
+		re := placeholderRegex
+		// A value that is only a placeholder takes the replacement as-is, so lists and maps keep their type.
+		if match := re.FindStringSubmatch(v); match != nil && match[0] == v {
+			if replacement, ok := replacements[match[1]]; ok {
+				if _, isString := replacement.(string); !isString {
+					return replacement
+				}
 			}
-			// If no replacement is found, return the original match.
-			return match
-		})
+		}
+		// Outputs within a string, e.g. a database host in a DSN, are substituted once resolved.
+		if outputs := outputReplacements(v, replacements); len(outputs) > 0 {
+			var keys []string
+			var inputs []interface{}
+			for key, output := range outputs {
+				keys = append(keys, key)
+				inputs = append(inputs, output)
+			}
+			return pulumi.All(inputs...).ApplyT(func(resolved []interface{}) string {
+				resolvedReplacements := map[string]interface{}{}
+				for key, value := range replacements {
+					resolvedReplacements[key] = value
+				}
+				for i, key := range keys {
+					resolvedReplacements[key] = resolved[i]
+				}
+				return replacePlaceholders(v, resolvedReplacements)
+			}).(pulumi.StringOutput)
+		}
+		return replacePlaceholders(v, replacements)
 	case map[string]interface{}:
 		for key, value := range v {
 			v[key] = substitutePlaceholders(value, replacements)
@@ -176,6 +424,39 @@ func substitutePlaceholders(input interface{}, replacements map[string]interface
 	}
 }
 
+// outputReplacements returns the replacements of the placeholders in value that are Pulumi inputs, keyed by placeholder.
+func outputReplacements(
+	value string,
+	replacements map[string]interface{},
+) map[string]pulumi.Input {
+
+	outputs := map[string]pulumi.Input{}
+	for _, match := range placeholderRegex.FindAllStringSubmatch(value, -1) {
+		if output, ok := replacements[match[1]].(pulumi.Input); ok {
+			outputs[match[1]] = output
+		}
+	}
+	return outputs
+}
+
+// replacePlaceholders replaces the ${key} placeholders of value with the string form of their replacement.
+func replacePlaceholders(
+	value string,
+	replacements map[string]interface{},
+) string {
+
+	return placeholderRegex.ReplaceAllStringFunc(value, func(match string) string {
+		// Extract the key name without the ${ and }.
+		key := strings.TrimSuffix(strings.TrimPrefix(match, "${"), "}")
+		if replacement, ok := replacements[key]; ok {
+			// Convert the replacement value to a string.
+			return fmt.Sprintf("%v", replacement)
+		}
+		// If no replacement is found, return the original match.
+		return match
+	})
+}
+
 // Convert map[string]interface{} to pulumi.MapInput
 func convertToPulumiMap(input map[string]interface{}) pulumi.MapInput {
 	pulumiMap := pulumi.Map{}
@@ -207,3 +488,12 @@ func GenerateRandomString(
 	}
 	return string(b)
 }
+
+// NewResourceError wraps err with the subsystem and the resource it failed on.
+func NewResourceError(
+	subsystem string,
+	resource string,
+	err error,
+) error {
+	return &ResourceError{Subsystem: subsystem, Resource: resource, Err: err}
+}
@@ -7,6 +7,70 @@ var (
 		"kubeflow",
 	}
 
+	// `project:stage` values, in order; each stage also deploys the stages before it.
+	StageNetwork     = "network"  // VPC and subnets
+	StageCluster     = "cluster"  // GKE and its node pools
+	StagePlatform    = "platform" // ingress controller, certificates, namespaces, IAM, registries, storage and databases of the tools
+	StageTool        = "tool"     // Helm releases of the MLOps target and add-ons
+	DeploymentStages = []string{StageNetwork, StageCluster, StagePlatform, StageTool}
+
+	// `gke:mode` values.
+	GKEModeStandard  = "standard"  // regional cluster running the node pools of the stack
+	GKEModeAutopilot = "autopilot" // GKE provisions the nodes for the compute classes the workloads select
+
+	// Compute classes the dedicated workloads select on Autopilot, in place of the `dedicated` node pool label.
+	autopilotNodeSelectors = map[string]map[string]string{
+		"highmem": {"cloud.google.com/compute-class": "Balanced"},
+		"highcpu": {"cloud.google.com/compute-class": "Scale-Out"},
+		"gpu":     {"cloud.google.com/compute-class": "Accelerator"},
+	}
+	// GPU the Accelerator compute class provisions, unless `gke:autopilotAccelerator` is set.
+	AutopilotDefaultAccelerator = "nvidia-l4"
+
+	// `certManager:acme` values.
+	ACMEProduction = "production"
+	ACMEStaging    = "staging"
+	// `certManager:solver` values: a certificate per host through HTTP-01, or a wildcard one through DNS-01.
+	CertSolverHTTP01 = "http01"
+	CertSolverDNS01  = "dns01"
+
+	// Cloud Armor preconfigured WAF rules evaluated by default ( `cloudArmor:wafRules` ).
+	CloudArmorDefaultWAFRules = []string{"sqli-v33-stable", "xss-v33-stable"}
+
+	// Add-ons can be deployed alongside any MLOps target.
+	MLOpsAllowedAddons = []string{
+		"jupyterhub",
+		"labelstudio",
+	}
+
+	// Settings each tool can supply to the `${...}` placeholders of its values templates ( `values/<tool>/*.yaml` ),
+	// across all of its modes and overlays.
+	ValuesSettings = map[string][]string{
+		"flyte": {
+			"gcpProjectId", "dbHost", "dbPassword", "dbName", "dbUsername", "gcsbucket", "hostName",
+			"AdminServiceAccount", "PropellerServiceAccount", "SchedulerServiceAccount", "DatacatalogServiceAccount",
+			"WorkersServiceAccount", "BinaryServiceAccount", "binaryKSA", "whitelistedIPs", "LetsEncrypt",
+			"flyteProjects", "flyteDomains", "clusterResourceCustomData", "authURL", "authSignIn",
+		},
+		"jupyterhub": {
+			"hostName", "allowedDomain", "oauthSecretName", "userServiceAccount", "homeStorageSize", "gcsbucket",
+			"whitelistedIPs", "letsEncrypt", "highmemNodeSelector", "highcpuNodeSelector", "gpuNodeSelector",
+		},
+		"labelstudio": {
+			"gcpProjectId", "hostName", "gcsbucket", "serviceAccount", "dbHost", "dbName", "dbUsername",
+			"dbPasswordSecret", "whitelistedIPs", "letsEncrypt", "kubeServiceAccount", "authURL", "authSignIn",
+		},
+		"mlrun": {
+			"gcsbucket", "hostName", "registryURL", "registrySecretName", "minioSecretName", "minioUser",
+			"minioPipelinesPassword", "grafanaSecretName", "jupyterSecretName", "workloadServiceAccount",
+			"dbHost", "dbPassword", "dbName", "dbUsername",
+		},
+		"oauth2proxy": {
+			"domain", "hostName", "secretName", "emailDomains", "googleGroups", "googleAdminEmail", "googleADC",
+			"kubeServiceAccount", "serviceAccountEmail",
+		},
+	}
+
 	// Recommended [https://googlecloudplatform.github.io/kubeflow-gke-docs/dev/docs/deploy/project-setup/#setting-up-a-project]
 	gcpServices = []string{
 		"serviceusage.googleapis.com",
@@ -26,6 +90,7 @@ var (
 		"cloudbuild.googleapis.com",
 		"certificatemanager.googleapis.com",
 		"artifactregistry.googleapis.com",
+		"dns.googleapis.com",
 		// OIDC
 		"securitycenter.googleapis.com",
 	}
@@ -25,13 +25,13 @@ func CreateIAMResources(
 
 	serviceAccounts, err := createServiceAccounts(ctx, projectConfig, IAM)
 	if err != nil {
-		return nil, fmt.Errorf("IAM service account: %w", err)
+		return nil, global.NewResourceError("iam", "service accounts", err)
 	}
 	if err := createIAMBindings(ctx, projectConfig, IAM, serviceAccounts); err != nil {
-		return nil, err
+		return nil, global.NewResourceError("iam", "role bindings", err)
 	}
 	if err := createIAMPolicyMembers(ctx, projectConfig, IAM, serviceAccounts); err != nil {
-		return nil, fmt.Errorf("IAM Role Policy Members: %w", err)
+		return nil, global.NewResourceError("iam", "policy members", err)
 	}
 
 	return serviceAccounts, nil
@@ -56,6 +56,24 @@ func createIAMBindings(
 			}
 		}
 
+		// The Kubernetes Service Accounts allowed to act as the Google Service Account.
+		if iamInfo.WorkloadIdentityBinding != nil {
+			identityNamespace := pulumi.Sprintf("%s.svc.id.goog", projectConfig.ProjectId)
+
+			var members pulumi.StringArray
+			for _, svcBind := range iamInfo.WorkloadIdentityBinding {
+				members = append(members, pulumi.Sprintf("serviceAccount:%s[%s]", identityNamespace, svcBind))
+			}
+			resourceName := fmt.Sprintf("%s-%s-workload-identity-binding", projectConfig.ResourceNamePrefix, roleName)
+			if _, err := serviceaccount.NewIAMBinding(ctx, resourceName, &serviceaccount.IAMBindingArgs{
+				ServiceAccountId: serviceAccounts[roleName].ServiceAccount.ID(),
+				Role:             pulumi.String("roles/iam.workloadIdentityUser"),
+				Members:          members,
+			}); err != nil {
+				return err
+			}
+		}
+
 		if !iamInfo.CreateRole {
 			continue
 		}
@@ -67,23 +85,6 @@ func createIAMBindings(
 		if _, err = createIAMServiceCustomRoleBinding(ctx, projectConfig, roleName, serviceAccounts, newRole); err != nil {
 			return err
 		}
-
-		if iamInfo.WorkloadIdentityBinding != nil {
-			identityNamespace := pulumi.Sprintf("%s.svc.id.goog", projectConfig.ProjectId)
-
-			resourceName := fmt.Sprintf("%s-%s-workload-identity-binding", projectConfig.ResourceNamePrefix, roleName)
-			for _, svcBind := range iamInfo.WorkloadIdentityBinding {
-				if _, err = serviceaccount.NewIAMBinding(ctx, resourceName, &serviceaccount.IAMBindingArgs{
-					ServiceAccountId: serviceAccounts[roleName].ServiceAccount.ID(),
-					Role:             pulumi.String("roles/iam.workloadIdentityUser"),
-					Members: pulumi.StringArray{
-						pulumi.Sprintf("serviceAccount:%s[%s]", identityNamespace, svcBind),
-					},
-				}); err != nil {
-					return err
-				}
-			}
-		}
 	}
 	return nil
 }
@@ -62,3 +62,13 @@ func (iam IAM) Validate(ctx *pulumi.Context) error {
 
 	return nil
 }
+
+// WithoutKeys returns a copy of the IAM configuration that creates no Service Account keys.
+func WithoutKeys(iamConfig map[string]IAM) map[string]IAM {
+	keyless := make(map[string]IAM, len(iamConfig))
+	for name, iamInfo := range iamConfig {
+		iamInfo.CreateKey = false
+		keyless[name] = iamInfo
+	}
+	return keyless
+}
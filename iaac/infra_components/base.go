@@ -1,7 +1,10 @@
 package infracomponents
 
 import (
+	"fmt"
+	"mlops/dns"
 	"mlops/global"
+	"sort"
 
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/helm/v3"
@@ -24,11 +27,16 @@ func CreateInfraComponents(
 	var nginxController *helm.Release
 
 	if infraComponents.NginxIngress {
-		nginxController, err = deployNginxController(ctx, projectConfig, k8sProvider)
+		nginxController, err = sharedRelease(NginxControllerHelmChart, func() (*helm.Release, error) {
+			return deployNginxController(ctx, projectConfig, k8sProvider)
+		})
 		if err != nil {
-			return nil, "", err
+			return nil, "", global.NewResourceError(subsystem, "nginx controller", err)
 		}
 		dependencies = append(dependencies, nginxController)
+		if err := createHostRecords(ctx, projectConfig, infraComponents); err != nil {
+			return nil, "", global.NewResourceError(subsystem, "host records", err)
+		}
 	}
 	if infraComponents.CertManager {
 		// If nginxController exists, add it as a dependency.
@@ -38,13 +46,96 @@ func CreateInfraComponents(
 		}
 		certManagerIssuer, err = deployCertManager(ctx, projectConfig, namespace, k8sProvider, infraComponents, opts...)
 		if err != nil {
-			return nil, LetsEncrypt, err
+			return nil, ClusterIssuerName(projectConfig), global.NewResourceError(subsystem, "cert-manager", err)
+		}
+		dependencies = append(dependencies, certManagerIssuer)
+		if projectConfig.OAuth2Proxy.Enabled {
+			oauth2Proxy, err := sharedRelease(OAuth2ProxyHelmChart, func() (*helm.Release, error) {
+				return deployOAuth2Proxy(ctx, projectConfig, k8sProvider, certManagerIssuer, pulumi.DependsOn(dependencies))
+			})
+			if err != nil {
+				return nil, ClusterIssuerName(projectConfig), global.NewResourceError(subsystem, "oauth2-proxy", err)
+			}
+			dependencies = append(dependencies, oauth2Proxy)
 		}
 		if infraComponents.Ingress {
-			deployIngress(ctx, projectConfig, namespace, infraComponents)
+			// Ingresses need the controller to serve them and the issuer to get their certificates.
+			ingresses, err := deployIngress(ctx, projectConfig, namespace, k8sProvider, infraComponents, dependencies)
+			if err != nil {
+				return nil, ClusterIssuerName(projectConfig), global.NewResourceError(subsystem, fmt.Sprintf("%s ingresses", namespace), err)
+			}
+			dependencies = append(dependencies, ingresses...)
 		}
-		dependencies = append(dependencies, certManagerIssuer)
 	}
 
-	return dependencies, LetsEncrypt, nil
+	return dependencies, ClusterIssuerName(projectConfig), nil
+}
+
+// sharedRelease returns the cluster-wide release registered under key, deploying it on first use.
+// The MLOps target and any add-ons share the same cluster, so nginx and cert-manager must only be installed once.
+func sharedRelease(
+	key string,
+	deploy func() (*helm.Release, error),
+) (*helm.Release, error) {
+
+	if release, ok := platformReleases[key]; ok {
+		return release, nil
+	}
+	release, err := deploy()
+	if err != nil {
+		return nil, err
+	}
+	platformReleases[key] = release
+	return release, nil
+}
+
+// NginxController returns the nginx controller release, or nil when no tool deployed it.
+func NginxController() *helm.Release {
+	return platformReleases[NginxControllerHelmChart]
+}
+
+// Hosts returns the hostnames exposed by the deployed components, sorted.
+func Hosts() []string {
+	var hosts []string
+	for host := range exposedHosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// createHostRecords points the DNS records of every host the component exposes at the nginx controller,
+// or at the Global Load Balancer in front of it.
+func createHostRecords(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	infraComponents InfraComponents,
+) error {
+
+	hosts := []string{infraComponents.Domain}
+	if infraComponents.Ingress {
+		for _, ingress := range infraComponents.IngressMap {
+			hosts = append(hosts, fmt.Sprintf("%s.%s", ingress.DNS, projectConfig.Domain))
+		}
+	}
+	if projectConfig.OAuth2Proxy.Enabled {
+		hosts = append(hosts, fmt.Sprintf("%s.%s", OAuth2ProxyDNS, projectConfig.Domain))
+	}
+	address := nginxControllerAddress
+	if projectConfig.LoadBalancer.Enabled {
+		address = projectConfig.LoadBalancer.Address
+	}
+	recorded := make(map[string]bool)
+	for _, host := range hosts {
+		// Several Ingresses can share a host, e.g. the HTTP and gRPC routes of a tool.
+		if recorded[host] {
+			continue
+		}
+		recorded[host] = true
+		exposedHosts[host] = true
+		if err := dns.CreateRecord(ctx, projectConfig, host, address); err != nil {
+			return err
+		}
+	}
+	return nil
 }
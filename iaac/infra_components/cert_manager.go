@@ -3,6 +3,7 @@ package infracomponents
 import (
 	"fmt"
 	"mlops/global"
+	"mlops/iam"
 
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/helm/v3"
@@ -18,30 +19,100 @@ func deployCertManager(
 	opts ...pulumi.ResourceOption,
 ) (*helm.Release, error) {
 
+	certManagerRelease, err := sharedRelease(CertManagerHelmChart, func() (*helm.Release, error) {
+		return installCertManager(ctx, projectConfig, k8sProvider, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The wildcard certificate already covers the namespace host.
+	if infraComponents.Certificate && infraComponents.Domain != "" && !projectConfig.Certificates.Wildcard {
+		err = configGroup(ctx, projectConfig, namespace, certificateYAML(projectConfig, namespace), certManagerRelease, k8sProvider, infraComponents.DependsOn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return certManagerRelease, nil
+}
+
+// installCertManager installs the cert-manager Helm chart, including its CRDs, into its own namespace,
+// followed by the ClusterIssuer shared by every namespace and, with DNS-01, the wildcard certificate.
+func installCertManager(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+	opts ...pulumi.ResourceOption,
+) (*helm.Release, error) {
+
+	// Set the installCRDs value explicitly
+	baseValues := map[string]interface{}{
+		"installCRDs": true,
+	}
+	if projectConfig.Certificates.Wildcard {
+		serviceAccountEmail, err := configureDNS01Solver(ctx, projectConfig)
+		if err != nil {
+			return nil, err
+		}
+		baseValues["serviceAccount"] = map[string]interface{}{
+			"annotations": map[string]interface{}{
+				"iam.gke.io/gcp-service-account": serviceAccountEmail,
+			},
+		}
+	}
+
+	// Resolve the chart coordinates and values overlays from `helm:certManager`.
+	chart, err := global.ConfigureHelmChart(ctx, "certManager", global.HelmChartConfig{
+		Chart:   CertManagerHelmChart,
+		Repo:    CertManagerHelmChartRepo,
+		Version: CertManagerHelmChartVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	values, err := global.BuildValues(baseValues, nil, chart.Values...)
+	if err != nil {
+		return nil, err
+	}
+
 	resourceName := fmt.Sprintf("%s-cert-manager", projectConfig.ResourceNamePrefix)
-	certManagerRelease, err := helm.NewRelease(ctx, resourceName, &helm.ReleaseArgs{
-		Name:            pulumi.String("cert-manager"),
+	release, err := helm.NewRelease(ctx, resourceName, &helm.ReleaseArgs{
+		Name:            pulumi.String(CertManagerHelmChart),
 		Namespace:       pulumi.String(CertManagerNamespace),
 		CreateNamespace: pulumi.Bool(true),
-		Chart:           pulumi.String(CertManagerHelmChart),
-		Version:         pulumi.String(CertManagerHelmChartVersion),
+		Chart:           pulumi.String(chart.Chart),
+		Version:         pulumi.String(chart.Version),
 		RepositoryOpts: &helm.RepositoryOptsArgs{
-			Repo: pulumi.String(CertManagerHelmChartRepo),
+			Repo: pulumi.String(chart.Repo),
 		},
 		// Do not skip installing CRDs
 		SkipCrds: pulumi.Bool(false),
-		// Set the installCRDs value explicitly
-		Values: pulumi.Map{
-			"installCRDs": pulumi.Bool(true),
-		},
-		Timeout: pulumi.Int(300),
+		Values:   values,
+		Timeout:  pulumi.Int(300),
 	}, append(opts, pulumi.Provider(k8sProvider))...)
-
 	if err != nil {
 		return nil, err
 	}
-	if err := configGroup(ctx, projectConfig, namespace, certManagerRelease, k8sProvider, infraComponents); err != nil {
+
+	resources := clusterIssuerYAML(ctx, projectConfig)
+	if projectConfig.Certificates.Wildcard {
+		merge(resources, wildcardCertificateYAML(projectConfig))
+	}
+	if err := configGroup(ctx, projectConfig, "cluster", resources, release, k8sProvider, nil); err != nil {
 		return nil, err
 	}
-	return certManagerRelease, nil
+	return release, nil
+}
+
+// configureDNS01Solver creates the Google Service Account cert-manager edits the Cloud DNS records of the
+// DNS-01 challenges with, bound to its Kubernetes Service Account through Workload Identity, and returns its e-mail.
+func configureDNS01Solver(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+) (pulumi.StringOutput, error) {
+
+	serviceAccounts, err := iam.CreateIAMResources(ctx, projectConfig, CertManagerIAM)
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	return serviceAccounts["dns01"].Email, nil
 }
@@ -3,7 +3,9 @@ package infracomponents
 import (
 	"fmt"
 	"mlops/global"
+	"sort"
 
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
 	metaV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/meta/v1"
 	networkingv1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/networking/v1"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -14,16 +16,29 @@ func deployIngress(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	namespace string,
+	k8sProvider *kubernetes.Provider,
 	infraComponents InfraComponents,
-) error {
+	dependencies []pulumi.Resource,
+) ([]pulumi.Resource, error) {
+
 	IngressMap := infraComponents.IngressMap
+	serviceRefs := make([]string, 0, len(IngressMap))
+	for serviceRef := range IngressMap {
+		serviceRefs = append(serviceRefs, serviceRef)
+	}
+	sort.Strings(serviceRefs)
+
+	dependsOn := append(append([]pulumi.Resource{}, dependencies...), infraComponents.DependsOn...)
 	// Loop over the map and create an Ingress resource for each configuration.
-	for serviceRef, cfg := range IngressMap {
-		if err := createIngress(ctx, projectConfig, serviceRef, namespace, cfg); err != nil {
-			return err
+	var ingresses []pulumi.Resource
+	for _, serviceRef := range serviceRefs {
+		ingress, err := createIngress(ctx, projectConfig, serviceRef, namespace, IngressMap[serviceRef], k8sProvider, dependsOn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the %s Ingress: %w", serviceRef, err)
 		}
+		ingresses = append(ingresses, ingress)
 	}
-	return nil
+	return ingresses, nil
 }
 
 // buildIngressPaths converts a slice of IngressPathConfig into a slice of HTTPIngressPathArgs.
@@ -63,22 +78,40 @@ func createIngress(
 	serviceRef string,
 	namespace string,
 	cfg IngressConfig,
-) error {
+	k8sProvider *kubernetes.Provider,
+	dependsOn []pulumi.Resource,
+) (*networkingv1.Ingress, error) {
 
 	host := fmt.Sprintf("%s.%s", cfg.DNS, projectConfig.Domain)
-
 	ingressName := serviceRef + "-ingress"
-	_, err := networkingv1.NewIngress(ctx, ingressName, &networkingv1.IngressArgs{
+	annotations := pulumi.StringMap{
+		"kubernetes.io/ingress.class":                        pulumi.String("nginx"),
+		"nginx.ingress.kubernetes.io/ssl-redirect":           pulumi.String("true"),
+		"nginx.ingress.kubernetes.io/whitelist-source-range": pulumi.String(projectConfig.WhitelistedIPs),
+	}
+	// Without the wildcard certificate, cert-manager issues one for the host through HTTP-01.
+	tlsSecretName := pulumi.StringPtr(ingressName + "-tls")
+	if projectConfig.Certificates.Wildcard {
+		tlsSecretName = nil
+	} else {
+		annotations["cert-manager.io/cluster-issuer"] = pulumi.String(ClusterIssuerName(projectConfig))
+		annotations["acme.cert-manager.io/http01-edit-in-place"] = pulumi.String("true")
+	}
+	if cfg.GRPC {
+		annotations["nginx.ingress.kubernetes.io/backend-protocol"] = pulumi.String("GRPC")
+	}
+	if projectConfig.OAuth2Proxy.Enabled && !cfg.Public {
+		for key, value := range authAnnotations(projectConfig) {
+			annotations[key] = value
+		}
+	}
+
+	resourceName := fmt.Sprintf("%s-%s-%s", projectConfig.ResourceNamePrefix, namespace, ingressName)
+	return networkingv1.NewIngress(ctx, resourceName, &networkingv1.IngressArgs{
 		Metadata: &metaV1.ObjectMetaArgs{
-			Name:      pulumi.String(ingressName),
-			Namespace: pulumi.String(namespace),
-			Annotations: pulumi.StringMap{
-				"cert-manager.io/issuer":                             pulumi.String("letsencrypt-production"),
-				"kubernetes.io/ingress.class":                        pulumi.String("nginx"),
-				"nginx.ingress.kubernetes.io/ssl-redirect":           pulumi.String("true"),
-				"acme.cert-manager.io/http01-edit-in-place":          pulumi.String("true"),
-				"nginx.ingress.kubernetes.io/whitelist-source-range": pulumi.String(projectConfig.WhitelistedIPs),
-			},
+			Name:        pulumi.String(ingressName),
+			Namespace:   pulumi.String(namespace),
+			Annotations: annotations,
 		},
 		Spec: networkingv1.IngressSpecArgs{
 			IngressClassName: pulumi.String("nginx"),
@@ -90,16 +123,18 @@ func createIngress(
 					},
 				},
 			},
-			// TLS configuration: cert-manager will issue a certificate for this host.
+			// TLS configuration: cert-manager issues a certificate for this host, or nginx serves the wildcard one.
 			Tls: networkingv1.IngressTLSArray{
 				networkingv1.IngressTLSArgs{
 					Hosts: pulumi.StringArray{
 						pulumi.String(host),
 					},
-					SecretName: pulumi.String(ingressName + "-tls"),
+					SecretName: tlsSecretName,
 				},
 			},
 		},
-	})
-	return err
+	},
+		pulumi.DependsOn(dependsOn),
+		pulumi.Provider(k8sProvider),
+	)
 }
@@ -9,33 +9,25 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
-// configGroup aggregates the YAML resources and creates a ConfigGroup for each.
+// configGroup creates a ConfigGroup for each of the given YAML manifests.
 // The certManagerRelease parameter is used as a dependency so that the resources are created only after cert-manager is deployed.
 func configGroup(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
-	namespace string,
+	scope string,
+	resources map[string]string,
 	certManagerRelease pulumi.Resource,
 	k8sProvider *kubernetes.Provider,
-	infraComponents InfraComponents,
+	dependsOn []pulumi.Resource,
 ) error {
-	// Create a map to hold resource names and YAML manifests.
-	resources := make(map[string]string)
-
-	if infraComponents.CertManagerIssuer {
-		merge(resources, certManagerIssuerYAML(ctx, namespace))
-	}
-	if infraComponents.Certificate && infraComponents.Domain != "" {
-		merge(resources, certificateYAML(projectConfig, namespace))
-	}
 
 	// Iterate over the collected resources and create a ConfigGroup for each.
 	for name, resourceYAML := range resources {
-		resourceName := fmt.Sprintf("%s-cert-manager-%s", projectConfig.ResourceNamePrefix, name)
+		resourceName := fmt.Sprintf("%s-%s-cert-manager-%s", projectConfig.ResourceNamePrefix, scope, name)
 		_, err := yaml.NewConfigGroup(ctx, resourceName, &yaml.ConfigGroupArgs{
 			YAML: []string{resourceYAML},
 		},
-			pulumi.DependsOn([]pulumi.Resource{certManagerRelease}),
+			pulumi.DependsOn(append([]pulumi.Resource{certManagerRelease}, dependsOn...)),
 			pulumi.Provider(k8sProvider),
 		)
 		if err != nil {
@@ -45,31 +37,70 @@ func configGroup(
 	return nil
 }
 
-func certManagerIssuerYAML(
+// clusterIssuerYAML returns the Let's Encrypt ClusterIssuer shared by every namespace. It solves DNS-01 through
+// Cloud DNS when the wildcard certificate is used, and HTTP-01 through nginx otherwise.
+func clusterIssuerYAML(
 	ctx *pulumi.Context,
-	namespace string,
+	projectConfig global.ProjectConfig,
 ) map[string]string {
+
 	email := global.ValidateEmail(ctx)
+	solver := `
+    - http01:
+        ingress:
+          ingressClassName: nginx`
+	if projectConfig.Certificates.Wildcard {
+		solver = fmt.Sprintf(`
+    - dns01:
+        cloudDNS:
+          project: %s`, projectConfig.ProjectId)
+	}
+	issuer := ClusterIssuerName(projectConfig)
 	issuerYAML := fmt.Sprintf(`
 apiVersion: cert-manager.io/v1
-kind: Issuer
+kind: ClusterIssuer
 metadata:
   name: %s
-  namespace: %s
 spec:
   acme:
-    server: https://acme-v02.api.letsencrypt.org/directory
+    server: %s
     email: %s
     privateKeySecretRef:
       name: %s
-    solvers:
-    - http01:
-        ingress:
-          ingressClassName: nginx
-`, LetsEncrypt, namespace, email, LetsEncrypt)
+    solvers:%s
+`, issuer, acmeServers[projectConfig.Certificates.ACME], email, issuer, solver)
+
+	return map[string]string{
+		"cluster-issuer": issuerYAML,
+	}
+}
+
+// wildcardCertificateYAML returns the certificate of the domain and all its hostnames, kept in the nginx namespace
+// and served by the controller as its default certificate.
+func wildcardCertificateYAML(
+	projectConfig global.ProjectConfig,
+) map[string]string {
+
+	certYAML := fmt.Sprintf(`
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: %s-cert
+  namespace: %s
+spec:
+  secretName: %s
+  issuerRef:
+    name: %s
+    kind: ClusterIssuer
+  commonName: "*.%s"
+  dnsNames:
+    - "*.%s"
+    - %s
+`, wildcardSecretName, NginxControllerNamespace, wildcardSecretName, ClusterIssuerName(projectConfig),
+		projectConfig.Domain, projectConfig.Domain, projectConfig.Domain)
 
 	return map[string]string{
-		"issuer": issuerYAML,
+		"wildcard-certificate": certYAML,
 	}
 }
 
@@ -89,11 +120,11 @@ spec:
   secretName: %s-secret-tls
   issuerRef:
     name: %s
-    kind: Issuer
+    kind: ClusterIssuer
   commonName: %s
   dnsNames:
     - %s
-`, namespace, namespace, namespace, LetsEncrypt, DNS, DNS)
+`, namespace, namespace, namespace, ClusterIssuerName(projectConfig), DNS, DNS)
 
 	return map[string]string{
 		"certificate": certYAML,
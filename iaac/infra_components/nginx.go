@@ -3,34 +3,131 @@ package infracomponents
 import (
 	"fmt"
 	"mlops/global"
+	"mlops/vpc"
+	"strings"
 
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/compute"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/helm/v3"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
 )
 
+// deployNginxController installs ingress-nginx behind a reserved regional address, so the IP the DNS records
+// and the Let's Encrypt challenges rely on survives the Service being recreated. Behind the Global Load Balancer
+// the Service is only reachable through its NEGs, so clients cannot bypass Cloud Armor and IAP.
 func deployNginxController(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	k8sProvider *kubernetes.Provider,
 ) (*helm.Release, error) {
 
+	var (
+		service      map[string]interface{}
+		nginxConfig  map[string]interface{}
+		dependencies []pulumi.Resource
+	)
+	if projectConfig.LoadBalancer.Enabled {
+		if config.GetBool(ctx, "nginx:restrictSourceRanges") {
+			ctx.Log.Warn("`nginx:restrictSourceRanges` has no effect behind the Global Load Balancer; Cloud Armor and the ingress allowlists restrict the clients.", nil)
+		}
+		service = map[string]interface{}{
+			"type":        "ClusterIP",
+			"annotations": vpc.NEGAnnotations(projectConfig),
+		}
+		// Requests arrive from the Google Front End proxies; the client address the ingress allowlists check is
+		// the one in X-Forwarded-For ahead of the load balancer address.
+		trustedProxies := projectConfig.LoadBalancer.Address.ApplyT(func(address string) string {
+			ranges := append([]string{}, vpc.GoogleFrontEndIPRanges...)
+			return strings.Join(append(ranges, address+"/32"), ",")
+		}).(pulumi.StringOutput)
+		nginxConfig = map[string]interface{}{
+			"use-forwarded-headers": "true",
+			"proxy-real-ip-cidr":    trustedProxies,
+		}
+	} else {
+		address, err := createNginxControllerAddress(ctx, projectConfig)
+		if err != nil {
+			return nil, err
+		}
+		nginxControllerAddress = address.Address
+		ctx.Export("nginxControllerAddress", nginxControllerAddress)
+		dependencies = append(dependencies, address)
+
+		service = map[string]interface{}{
+			"externalTrafficPolicy": "Local",
+			// Resolved by Pulumi once the address is reserved.
+			"loadBalancerIP": address.Address,
+		}
+		// Optionally only the allowlisted clients reach the load balancer at all.
+		if config.GetBool(ctx, "nginx:restrictSourceRanges") {
+			var sourceRanges []interface{}
+			for _, ip := range global.FormatStringIntoList(projectConfig.WhitelistedIPs) {
+				sourceRanges = append(sourceRanges, ip)
+			}
+			service["loadBalancerSourceRanges"] = sourceRanges
+		}
+	}
+
+	controller := map[string]interface{}{
+		"service": service,
+	}
+	if nginxConfig != nil {
+		controller["config"] = nginxConfig
+	}
+	// Ingresses without a certificate of their own are served the wildcard one.
+	if projectConfig.Certificates.Wildcard {
+		controller["extraArgs"] = map[string]interface{}{
+			"default-ssl-certificate": fmt.Sprintf("%s/%s", NginxControllerNamespace, wildcardSecretName),
+		}
+	}
+
+	// Resolve the chart coordinates and values overlays from `helm:nginx`.
+	chart, err := global.ConfigureHelmChart(ctx, "nginx", global.HelmChartConfig{
+		Chart:   NginxControllerHelmChart,
+		Repo:    NginxControllerHelmChartRepo,
+		Version: NginxControllerHelmChartVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	values, err := global.BuildValues(map[string]interface{}{
+		"controller": controller,
+	}, nil, chart.Values...)
+	if err != nil {
+		return nil, err
+	}
+
 	resourceName := fmt.Sprintf("%s-nginx-controller", projectConfig.ResourceNamePrefix)
 	return helm.NewRelease(ctx, resourceName, &helm.ReleaseArgs{
 		Name:            pulumi.String("ingress-nginx"),
 		Namespace:       pulumi.String(NginxControllerNamespace),
 		CreateNamespace: pulumi.Bool(true),
-		Chart:           pulumi.String(NginxControllerHelmChart),
-		Version:         pulumi.String(NginxControllerHelmChartVersion),
+		Chart:           pulumi.String(chart.Chart),
+		Version:         pulumi.String(chart.Version),
 		RepositoryOpts: &helm.RepositoryOptsArgs{
-			Repo: pulumi.String(NginxControllerHelmChartRepo),
-		},
-		Values: pulumi.Map{
-			"controller": pulumi.Map{
-				"service": pulumi.Map{
-					"externalTrafficPolicy": pulumi.String("Local"),
-				},
-			},
+			Repo: pulumi.String(chart.Repo),
 		},
-	}, pulumi.Provider(k8sProvider))
+		Values: values,
+	}, pulumi.Provider(k8sProvider), pulumi.DependsOn(dependencies))
+}
+
+// createNginxControllerAddress reserves the external regional IP of the nginx controller LoadBalancer Service.
+func createNginxControllerAddress(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+) (*compute.Address, error) {
+
+	resourceName := fmt.Sprintf("%s-nginx-ip-address", projectConfig.ResourceNamePrefix)
+	address, err := compute.NewAddress(ctx, resourceName, &compute.AddressArgs{
+		Project:     pulumi.String(projectConfig.ProjectId),
+		Name:        pulumi.String(resourceName),
+		Region:      pulumi.String(projectConfig.EnabledRegion.Region),
+		AddressType: pulumi.String("EXTERNAL"),
+		Description: pulumi.String("nginx Ingress Controller - Static IP Address"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve the nginx controller IP address: %w", err)
+	}
+	return address, nil
 }
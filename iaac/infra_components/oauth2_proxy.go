@@ -0,0 +1,203 @@
+package infracomponents
+
+import (
+	"fmt"
+	"mlops/global"
+	"mlops/iam"
+	"strings"
+
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/serviceaccount"
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
+	coreV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/core/v1"
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/helm/v3"
+	metaV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/meta/v1"
+	"github.com/pulumi/pulumi-random/sdk/v4/go/random"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// AuthSettings returns the values settings of the `<tool>/values-auth.yaml` templates, which put a tool's
+// ingress behind the oauth2-proxy login.
+func AuthSettings(
+	projectConfig global.ProjectConfig,
+) map[string]interface{} {
+
+	return map[string]interface{}{
+		"authURL":    fmt.Sprintf("http://%s.%s.svc.cluster.local/oauth2/auth", OAuth2ProxyHelmChart, OAuth2ProxyNamespace),
+		"authSignIn": fmt.Sprintf("https://%s.%s/oauth2/start?rd=$scheme://$host$escaped_request_uri", OAuth2ProxyDNS, projectConfig.Domain),
+	}
+}
+
+// authAnnotations returns the nginx annotations delegating the authentication of an Ingress to oauth2-proxy.
+func authAnnotations(
+	projectConfig global.ProjectConfig,
+) pulumi.StringMap {
+
+	settings := AuthSettings(projectConfig)
+	return pulumi.StringMap{
+		"nginx.ingress.kubernetes.io/auth-url":              pulumi.String(settings["authURL"].(string)),
+		"nginx.ingress.kubernetes.io/auth-signin":           pulumi.String(settings["authSignIn"].(string)),
+		"nginx.ingress.kubernetes.io/auth-response-headers": pulumi.String("X-Auth-Request-User,X-Auth-Request-Email"),
+	}
+}
+
+// deployOAuth2Proxy installs oauth2-proxy at `auth.<domain>` with its Google OAuth client and a generated cookie secret.
+// The session cookie is set on the whole domain, so one login covers every tool.
+func deployOAuth2Proxy(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+	certManagerRelease pulumi.Resource,
+	opts ...pulumi.ResourceOption,
+) (*helm.Release, error) {
+
+	proxyConfig := projectConfig.OAuth2Proxy
+	resourceName := fmt.Sprintf("%s-oauth2-proxy-ns", projectConfig.ResourceNamePrefix)
+	ns, err := coreV1.NewNamespace(ctx, resourceName, &coreV1.NamespaceArgs{
+		Metadata: &metaV1.ObjectMetaArgs{
+			Name: pulumi.String(OAuth2ProxyNamespace),
+		},
+	}, pulumi.Provider(k8sProvider))
+	if err != nil {
+		return nil, err
+	}
+	resourceName = fmt.Sprintf("%s-oauth2-proxy-cookie-secret", projectConfig.ResourceNamePrefix)
+	cookieSecret, err := random.NewRandomPassword(ctx, resourceName, &random.RandomPasswordArgs{
+		Length:  pulumi.Int(32),
+		Special: pulumi.Bool(false),
+	})
+	if err != nil {
+		return nil, err
+	}
+	resourceName = fmt.Sprintf("%s-oauth2-proxy-credentials", projectConfig.ResourceNamePrefix)
+	secret, err := coreV1.NewSecret(ctx, resourceName, &coreV1.SecretArgs{
+		Metadata: &metaV1.ObjectMetaArgs{
+			Name:      pulumi.String(oauth2ProxySecretName),
+			Namespace: ns.Metadata.Name(),
+		},
+		StringData: pulumi.StringMap{
+			"client-id":     pulumi.String(proxyConfig.ClientId),
+			"client-secret": proxyConfig.ClientSecret,
+			"cookie-secret": cookieSecret.Result,
+		},
+	},
+		pulumi.Provider(k8sProvider),
+		pulumi.DependsOn([]pulumi.Resource{ns}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oauth2-proxy credentials: %w", err)
+	}
+
+	valuesTemplates := []string{"oauth2proxy/values.yaml"}
+	var serviceAccountEmail interface{} = ""
+	if len(proxyConfig.AllowedGroups) > 0 {
+		valuesTemplates = append(valuesTemplates, "oauth2proxy/values-groups.yaml")
+		groupsEmail, err := configureGroupsLookup(ctx, projectConfig)
+		if err != nil {
+			return nil, err
+		}
+		serviceAccountEmail = groupsEmail
+	}
+
+	// Resolve the chart coordinates and values overlays from `helm:oauth2Proxy`.
+	chart, err := global.ConfigureHelmChart(ctx, "oauth2Proxy", global.HelmChartConfig{
+		Chart:   OAuth2ProxyHelmChart,
+		Repo:    OAuth2ProxyHelmChartRepo,
+		Version: OAuth2ProxyHelmChartVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	userSettings := map[string]interface{}{
+		"domain":              projectConfig.Domain,
+		"hostName":            fmt.Sprintf("%s.%s", OAuth2ProxyDNS, projectConfig.Domain),
+		"secretName":          oauth2ProxySecretName,
+		"emailDomains":        tomlList(proxyConfig.AllowedDomains),
+		"googleGroups":        tomlList(proxyConfig.AllowedGroups),
+		"googleAdminEmail":    proxyConfig.AdminEmail,
+		"googleADC":           len(proxyConfig.AllowedGroups) > 0,
+		"kubeServiceAccount":  oauth2ProxyKSA,
+		"serviceAccountEmail": serviceAccountEmail,
+	}
+	valuesMap, err := global.GetLayeredValues(valuesTemplates, userSettings, chart.Values...)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceName = fmt.Sprintf("%s-oauth2-proxy", projectConfig.ResourceNamePrefix)
+	release, err := helm.NewRelease(ctx, resourceName, &helm.ReleaseArgs{
+		Name:      pulumi.String(OAuth2ProxyHelmChart),
+		Namespace: pulumi.String(OAuth2ProxyNamespace),
+		Chart:     pulumi.String(chart.Chart),
+		Version:   pulumi.String(chart.Version),
+		RepositoryOpts: &helm.RepositoryOptsArgs{
+			Repo: pulumi.String(chart.Repo),
+		},
+		Values:  valuesMap,
+		Timeout: pulumi.Int(300),
+	}, append(opts,
+		pulumi.Provider(k8sProvider),
+		pulumi.DependsOn([]pulumi.Resource{ns, secret}),
+	)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deploy oauth2-proxy Helm chart: %w", err)
+	}
+
+	loginIngress := IngressConfig{
+		DNS: OAuth2ProxyDNS,
+		Paths: []IngressPathConfig{
+			{
+				Service: OAuth2ProxyHelmChart,
+				Port:    80,
+			},
+		},
+		Public: true,
+	}
+	if _, err := createIngress(ctx, projectConfig, "oauth2-proxy", OAuth2ProxyNamespace, loginIngress, k8sProvider, []pulumi.Resource{release, certManagerRelease}); err != nil {
+		return nil, fmt.Errorf("failed to create the oauth2-proxy Ingress: %w", err)
+	}
+	return release, nil
+}
+
+// configureGroupsLookup creates the Google Service Account oauth2-proxy runs as through Workload Identity and
+// returns its email. It signs its own tokens to impersonate the Workspace admin of the group lookups.
+func configureGroupsLookup(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+) (pulumi.StringOutput, error) {
+
+	serviceAccounts, err := iam.CreateIAMResources(ctx, projectConfig, OAuth2ProxyIAM)
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	serviceAccount := serviceAccounts["groups"]
+
+	resourceName := fmt.Sprintf("%s-oauth2-proxy-workload-identity", projectConfig.ResourceNamePrefix)
+	_, err = serviceaccount.NewIAMMember(ctx, resourceName, &serviceaccount.IAMMemberArgs{
+		ServiceAccountId: serviceAccount.ServiceAccount.Name,
+		Role:             pulumi.String("roles/iam.workloadIdentityUser"),
+		Member:           pulumi.Sprintf("serviceAccount:%s.svc.id.goog[%s/%s]", projectConfig.ProjectId, OAuth2ProxyNamespace, oauth2ProxyKSA),
+	})
+	if err != nil {
+		return pulumi.StringOutput{}, fmt.Errorf("failed to bind oauth2-proxy Workload Identity: %w", err)
+	}
+
+	resourceName = fmt.Sprintf("%s-oauth2-proxy-token-creator", projectConfig.ResourceNamePrefix)
+	_, err = serviceaccount.NewIAMMember(ctx, resourceName, &serviceaccount.IAMMemberArgs{
+		ServiceAccountId: serviceAccount.ServiceAccount.Name,
+		Role:             pulumi.String("roles/iam.serviceAccountTokenCreator"),
+		Member:           pulumi.Sprintf("serviceAccount:%s", serviceAccount.Email),
+	})
+	if err != nil {
+		return pulumi.StringOutput{}, fmt.Errorf("failed to let oauth2-proxy sign its tokens: %w", err)
+	}
+	return serviceAccount.Email, nil
+}
+
+// tomlList formats strings as a TOML array for the oauth2-proxy configuration file.
+func tomlList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, fmt.Sprintf("%q", value))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(quoted, ", "))
+}
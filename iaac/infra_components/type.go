@@ -1,14 +1,19 @@
 package infracomponents
 
+import (
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
 type InfraComponents struct {
-	CertManager       bool
-	NginxIngress      bool
-	Certificate       bool
-	CertManagerIssuer bool
-	Domain            string
-	Ingress           bool
-	IngressMap        map[string]IngressConfig
-	Minio             bool
+	CertManager  bool
+	NginxIngress bool
+	Certificate  bool
+	Domain       string
+	Ingress      bool
+	IngressMap   map[string]IngressConfig
+	Minio        bool
+	// Resources the namespaced components ( certificate, ingresses ) wait for, e.g. their namespace.
+	DependsOn []pulumi.Resource
 }
 
 // IngressPathConfig holds configuration for an individual path rule.
@@ -22,4 +27,8 @@ type IngressPathConfig struct {
 type IngressConfig struct {
 	DNS   string              // DNS prefix; for example, "grafana" leads to "grafana.example.com"
 	Paths []IngressPathConfig // one or more path rules for the Ingress
+	// Public skips the oauth2-proxy login, e.g. for the login host itself.
+	Public bool
+	// GRPC routes to gRPC backends, e.g. the API used by CLI clients.
+	GRPC bool
 }
@@ -1,8 +1,20 @@
 package infracomponents
 
+import (
+	"mlops/global"
+)
+
 // merge copies key-value pairs from src to dest.
 func merge(dest, src map[string]string) {
 	for k, v := range src {
 		dest[k] = v
 	}
 }
+
+// ClusterIssuerName returns the name of the Let's Encrypt ClusterIssuer of the configured endpoint.
+func ClusterIssuerName(
+	projectConfig global.ProjectConfig,
+) string {
+
+	return "letsencrypt-" + projectConfig.Certificates.ACME
+}
@@ -1,6 +1,17 @@
 package infracomponents
 
+import (
+	"mlops/global"
+	"mlops/iam"
+
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/helm/v3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
 var (
+	// Subsystem reported by the errors of the platform components.
+	subsystem = "infra-components"
+
 	NginxControllerNamespace        = "nginx-ingress"
 	NginxControllerHelmChart        = "ingress-nginx"
 	NginxControllerHelmChartVersion = "4.11.4"
@@ -11,5 +22,53 @@ var (
 	CertManagerHelmChartVersion = "v1.17.0"
 	CertManagerHelmChartRepo    = "https://charts.jetstack.io"
 
-	LetsEncrypt = "letsencrypt-production"
+	// Let's Encrypt endpoints of `certManager:acme`; the ClusterIssuer is named `letsencrypt-<endpoint>`.
+	acmeServers = map[string]string{
+		global.ACMEProduction: "https://acme-v02.api.letsencrypt.org/directory",
+		global.ACMEStaging:    "https://acme-staging-v02.api.letsencrypt.org/directory",
+	}
+	// Secret of the wildcard certificate, in the nginx namespace.
+	wildcardSecretName = "wildcard-tls"
+
+	// cert-manager solves the DNS-01 challenges in the Cloud DNS zone of the domain.
+	CertManagerIAM = map[string]iam.IAM{
+		"dns01": {
+			ResourceNamePrefix: "cert-manager",
+			DisplayName:        "cert-manager DNS-01 solver",
+			Roles: []string{
+				"roles/dns.admin",
+			},
+			CreateServiceAccount: true,
+			CreateMember:         true,
+			// The Kubernetes Service Account of the cert-manager controller.
+			WorkloadIdentityBinding: []string{
+				"cert-manager/cert-manager",
+			},
+		},
+	}
+
+	OAuth2ProxyNamespace        = "oauth2-proxy"
+	OAuth2ProxyHelmChart        = "oauth2-proxy"
+	OAuth2ProxyHelmChartVersion = "7.7.1"
+	OAuth2ProxyHelmChartRepo    = "https://oauth2-proxy.github.io/manifests"
+	// Login host of oauth2-proxy, `auth.<domain>`.
+	OAuth2ProxyDNS        = "auth"
+	oauth2ProxySecretName = "oauth2-proxy-credentials"
+	oauth2ProxyKSA        = "oauth2-proxy"
+
+	// Group checks go through the Admin SDK as a Workspace admin, impersonated by this account with domain-wide delegation.
+	OAuth2ProxyIAM = map[string]iam.IAM{
+		"groups": {
+			ResourceNamePrefix:   "oauth2-proxy",
+			DisplayName:          "oauth2-proxy Google Groups lookups",
+			CreateServiceAccount: true,
+		},
+	}
+
+	// Cluster-wide releases keyed by chart name; shared between the MLOps target and add-ons.
+	platformReleases = map[string]*helm.Release{}
+	// Reserved IP of the nginx controller, which the DNS records of the tool hostnames point at.
+	nginxControllerAddress pulumi.StringOutput
+	// Hostnames exposed through nginx, routed by the Global Load Balancer URL maps.
+	exposedHosts = map[string]bool{}
 )
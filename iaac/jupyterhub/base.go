@@ -0,0 +1,166 @@
+package jupyterhub
+
+import (
+	"fmt"
+	"mlops/global"
+	"mlops/iam"
+	infracomponents "mlops/infra_components"
+	"mlops/storage"
+
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/helm/v3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
+)
+
+var (
+	application      = "jupyterhub"
+	domainPrefix     = "jupyterhub"
+	namespace        = "jupyterhub"
+	helmChart        = "jupyterhub"
+	helmChartVersion = "4.1.0"
+	helmChartRepo    = "https://hub.jupyter.org/helm-chart/"
+	bucketName       = "jupyterhub-user-bucket-01"
+)
+
+// CreateJupyterHubResources deploys JupyterHub as an add-on next to the targeted MLOps tool.
+// Users log in through Google OAuth restricted to the allowed domain, get their home directory on a persistent disk
+// and reach the project GCS bucket through Workload Identity.
+func CreateJupyterHubResources(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+) error {
+
+	domain := fmt.Sprintf("%s.%s", domainPrefix, projectConfig.Domain)
+	hubConfig, err := configureJupyterHub(ctx, projectConfig, domain)
+	if err != nil {
+		return global.NewResourceError(application, "configuration", err)
+	}
+
+	infraComponents := infracomponents.InfraComponents{
+		CertManager:  true,
+		NginxIngress: true,
+		Domain:       domain,
+	}
+
+	serviceAccounts, err := iam.CreateIAMResources(ctx, projectConfig, JupyterHubIAM)
+	if err != nil {
+		return global.NewResourceError(application, "service accounts", err)
+	}
+	gcsBucket, err := storage.CreateObjectStorage(ctx, projectConfig, bucketName)
+	if err != nil {
+		return global.NewResourceError(application, "bucket", err)
+	}
+	if err := configureUserBucketAccess(ctx, projectConfig, serviceAccounts, gcsBucket); err != nil {
+		return global.NewResourceError(application, "bucket access", err)
+	}
+
+	dependencies, letsEncrypt, err := createKubernetesResources(ctx, projectConfig, infraComponents, k8sProvider, serviceAccounts, hubConfig)
+	if err != nil {
+		return global.NewResourceError(application, "kubernetes resources", err)
+	}
+	hubConfig.LetsEncrypt = letsEncrypt
+
+	if projectConfig.StageEnabled(global.StageTool) {
+		if err := deployJupyterHub(ctx, projectConfig, k8sProvider, hubConfig, dependencies); err != nil {
+			return global.NewResourceError(application, "release", err)
+		}
+	}
+	ctx.Export("jupyterhubURL", pulumi.Sprintf("https://%s", domain))
+	return nil
+}
+
+// configureJupyterHub reads the `jupyterhub:*` configuration and applies defaults for missing values.
+func configureJupyterHub(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	domain string,
+) (JupyterHubConfig, error) {
+
+	hubConfig := JupyterHubConfig{
+		Domain:          domain,
+		AllowedDomain:   config.Get(ctx, "jupyterhub:allowedDomain"),
+		HomeStorageSize: config.Get(ctx, "jupyterhub:homeStorageSize"),
+		GcsBucketName:   bucketName,
+		OAuthClientId:   config.Get(ctx, "jupyterhub:oauthClientId"),
+		OAuthSecret:     config.GetSecret(ctx, "jupyterhub:oauthClientSecret"),
+	}
+	if hubConfig.AllowedDomain == "" {
+		hubConfig.AllowedDomain = projectConfig.Domain
+	}
+	if hubConfig.HomeStorageSize == "" {
+		hubConfig.HomeStorageSize = defaultHomeStorageSize
+	}
+	if hubConfig.OAuthClientId == "" {
+		return hubConfig, fmt.Errorf("the JupyterHub add-on needs a Google OAuth client; set `jupyterhub:oauthClientId` and `jupyterhub:oauthClientSecret`")
+	}
+	return hubConfig, nil
+}
+
+func deployJupyterHub(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+	hubConfig JupyterHubConfig,
+	dependencies []pulumi.Resource,
+) error {
+
+	// Resolve the chart coordinates and values overlays from `helm:jupyterhub`.
+	chart, err := global.ConfigureHelmChart(ctx, "jupyterhub", global.HelmChartConfig{
+		Chart:   helmChart,
+		Repo:    helmChartRepo,
+		Version: helmChartVersion,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Values templates, embedded or overridden from `project:valuesDir`.
+	valuesTemplates := []string{"jupyterhub/values.yaml"}
+	// nginx serves the wildcard certificate instead of one issued for the host.
+	if projectConfig.Certificates.Wildcard {
+		valuesTemplates = append(valuesTemplates, "jupyterhub/values-wildcard.yaml")
+	}
+	// Build the replacement map using resolved strings.
+	userSettings := map[string]interface{}{
+		"hostName":           hubConfig.Domain,
+		"allowedDomain":      hubConfig.AllowedDomain,
+		"oauthSecretName":    oauthSecretName,
+		"userServiceAccount": userServiceAccountName,
+		"homeStorageSize":    hubConfig.HomeStorageSize,
+		"gcsbucket":          hubConfig.GcsBucketName,
+		"whitelistedIPs":     projectConfig.WhitelistedIPs,
+		"letsEncrypt":        hubConfig.LetsEncrypt,
+		// The profiles select a node pool, or a compute class on Autopilot.
+		"highmemNodeSelector": projectConfig.NodeSelector("highmem"),
+		"highcpuNodeSelector": projectConfig.NodeSelector("highcpu"),
+		"gpuNodeSelector":     projectConfig.NodeSelector("gpu"),
+	}
+
+	// Get the substituted values map.
+	valuesMap, err := global.GetLayeredValues(valuesTemplates, userSettings, chart.Values...)
+	if err != nil {
+		return err
+	}
+
+	resourceName := fmt.Sprintf("%s-jupyterhub", projectConfig.ResourceNamePrefix)
+	_, err = helm.NewRelease(ctx, resourceName, &helm.ReleaseArgs{
+		Name:      pulumi.String(application),
+		Namespace: pulumi.String(namespace),
+		Version:   pulumi.String(chart.Version),
+		RepositoryOpts: &helm.RepositoryOptsArgs{
+			Repo: pulumi.String(chart.Repo),
+		},
+		Chart:   pulumi.String(chart.Chart),
+		Values:  valuesMap,
+		Timeout: pulumi.Int(600),
+	},
+		pulumi.DependsOn(dependencies),
+		pulumi.Provider(k8sProvider),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to deploy JupyterHub Helm chart: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package jupyterhub
+
+import (
+	"fmt"
+	"mlops/global"
+	"mlops/iam"
+
+	"github.com/pulumi/pulumi-gcp/sdk/v6/go/gcp/storage"
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/serviceaccount"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// configureUserBucketAccess binds the single-user Kubernetes Service Account to the `users` Google Service Account
+// and grants the latter object access on the add-on bucket.
+func configureUserBucketAccess(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	serviceAccounts map[string]iam.ServiceAccountInfo,
+	gcsBucket *storage.Bucket,
+) error {
+
+	serviceAccount := serviceAccounts["users"]
+
+	resourceName := fmt.Sprintf("%s-jupyterhub-bucket-object-admin", projectConfig.ResourceNamePrefix)
+	_, err := storage.NewBucketIAMMember(ctx, resourceName, &storage.BucketIAMMemberArgs{
+		Bucket: gcsBucket.Name,
+		Role:   pulumi.String("roles/storage.objectAdmin"),
+		Member: serviceAccount.Member.Index(pulumi.Int(0)),
+	}, pulumi.DependsOn([]pulumi.Resource{gcsBucket, serviceAccount.ServiceAccount}))
+	if err != nil {
+		return fmt.Errorf("failed to grant JupyterHub users access to bucket: %w", err)
+	}
+
+	resourceName = fmt.Sprintf("%s-jupyterhub-workload-identity", projectConfig.ResourceNamePrefix)
+	_, err = serviceaccount.NewIAMMember(ctx, resourceName, &serviceaccount.IAMMemberArgs{
+		ServiceAccountId: serviceAccount.ServiceAccount.Name,
+		Role:             pulumi.String("roles/iam.workloadIdentityUser"),
+		Member:           pulumi.Sprintf("serviceAccount:%s.svc.id.goog[%s/%s]", projectConfig.ProjectId, namespace, userServiceAccountName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to bind JupyterHub Workload Identity: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package jupyterhub
+
+import (
+	"fmt"
+	"mlops/global"
+	"mlops/iam"
+	infracomponents "mlops/infra_components"
+
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
+	coreV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/core/v1"
+	metaV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/meta/v1"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func createKubernetesResources(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	infraComponents infracomponents.InfraComponents,
+	k8sProvider *kubernetes.Provider,
+	serviceAccounts map[string]iam.ServiceAccountInfo,
+	hubConfig JupyterHubConfig,
+) ([]pulumi.Resource, string, error) {
+
+	dependencies := []pulumi.Resource{}
+	ns, err := createJupyterHubNamespace(ctx, projectConfig, k8sProvider)
+	if err != nil {
+		return dependencies, "", err
+	}
+	oauthSecret, err := createOAuthSecret(ctx, projectConfig, k8sProvider, hubConfig, ns)
+	if err != nil {
+		return dependencies, "", err
+	}
+	userServiceAccount, err := createUserServiceAccount(ctx, projectConfig, k8sProvider, serviceAccounts["users"].Email, ns)
+	if err != nil {
+		return dependencies, "", err
+	}
+	dependencies, LetsEncrypt, err := infracomponents.CreateInfraComponents(ctx, projectConfig, namespace, k8sProvider, infraComponents)
+	if err != nil {
+		return dependencies, LetsEncrypt, err
+	}
+
+	dependsOn := append(dependencies,
+		oauthSecret,
+		userServiceAccount,
+	)
+	return dependsOn, LetsEncrypt, nil
+}
+
+func createJupyterHubNamespace(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+) (*coreV1.Namespace, error) {
+
+	resourceName := fmt.Sprintf("%s-jupyterhub-ns", projectConfig.ResourceNamePrefix)
+	return coreV1.NewNamespace(ctx, resourceName, &coreV1.NamespaceArgs{
+		Metadata: &metaV1.ObjectMetaArgs{
+			Name: pulumi.String(namespace),
+		},
+	}, pulumi.Provider(k8sProvider))
+}
+
+// createOAuthSecret stores the Google OAuth client credentials; the hub reads them through
+// the OAUTH_CLIENT_ID / OAUTH_CLIENT_SECRET environment variables so they never land in the Helm values.
+func createOAuthSecret(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+	hubConfig JupyterHubConfig,
+	ns *coreV1.Namespace,
+) (*coreV1.Secret, error) {
+
+	resourceName := fmt.Sprintf("%s-jupyterhub-oauth-secret", projectConfig.ResourceNamePrefix)
+	return coreV1.NewSecret(ctx, resourceName, &coreV1.SecretArgs{
+		Metadata: &metaV1.ObjectMetaArgs{
+			Namespace: ns.Metadata.Name(),
+			Name:      pulumi.String(oauthSecretName),
+		},
+		Type: pulumi.String("Opaque"),
+		StringData: pulumi.StringMap{
+			"client-id":     pulumi.String(hubConfig.OAuthClientId),
+			"client-secret": hubConfig.OAuthSecret,
+		},
+	},
+		pulumi.Provider(k8sProvider),
+		pulumi.DependsOn([]pulumi.Resource{ns}),
+	)
+}
+
+// createUserServiceAccount creates the Kubernetes Service Account used by single-user servers,
+// annotated for Workload Identity with the `users` Google Service Account.
+func createUserServiceAccount(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+	serviceAccountEmail pulumi.StringOutput,
+	ns *coreV1.Namespace,
+) (*coreV1.ServiceAccount, error) {
+
+	resourceName := fmt.Sprintf("%s-jupyterhub-user-sa", projectConfig.ResourceNamePrefix)
+	return coreV1.NewServiceAccount(ctx, resourceName, &coreV1.ServiceAccountArgs{
+		Metadata: &metaV1.ObjectMetaArgs{
+			Namespace: ns.Metadata.Name(),
+			Name:      pulumi.String(userServiceAccountName),
+			Annotations: pulumi.StringMap{
+				"iam.gke.io/gcp-service-account": serviceAccountEmail,
+			},
+		},
+	},
+		pulumi.Provider(k8sProvider),
+		pulumi.DependsOn([]pulumi.Resource{ns}),
+	)
+}
@@ -0,0 +1,15 @@
+package jupyterhub
+
+import (
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type JupyterHubConfig struct {
+	Domain          string
+	AllowedDomain   string
+	HomeStorageSize string
+	GcsBucketName   string
+	OAuthClientId   string
+	OAuthSecret     pulumi.StringOutput
+	LetsEncrypt     string
+}
@@ -0,0 +1,21 @@
+package jupyterhub
+
+import (
+	"mlops/iam"
+)
+
+var (
+	oauthSecretName        = "jupyterhub-oauth"
+	userServiceAccountName = "jupyterhub-user"
+	defaultHomeStorageSize = "10Gi"
+
+	// The single-user servers impersonate this service account through Workload Identity.
+	// Its access is granted on the add-on bucket only (see configureUserBucketAccess).
+	JupyterHubIAM = map[string]iam.IAM{
+		"users": {
+			ResourceNamePrefix:   "jupyterhub",
+			DisplayName:          "JupyterHub Single-User Servers",
+			CreateServiceAccount: true,
+		},
+	}
+)
@@ -0,0 +1,160 @@
+package labelstudio
+
+import (
+	"fmt"
+	"mlops/cloudsql"
+	"mlops/global"
+	"mlops/iam"
+	infracomponents "mlops/infra_components"
+	"mlops/storage"
+
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/compute"
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/helm/v3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var (
+	application      = "labelstudio"
+	domainPrefix     = "labelstudio"
+	namespace        = "labelstudio"
+	helmChart        = "label-studio"
+	helmChartVersion = "1.9.5"
+	helmChartRepo    = "https://charts.heartex.com/"
+	bucketName       = "labelstudio-data-bucket-01"
+)
+
+// CreateLabelStudioResources deploys the Label Studio annotation service as an add-on.
+// Its database lives on the shared CloudSQL instance and its source/target storage is a GCS bucket,
+// accessed with signed URLs through a dedicated Google Service Account bound with Workload Identity.
+func CreateLabelStudioResources(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+	gcpNetwork *compute.Network,
+) error {
+
+	domain := fmt.Sprintf("%s.%s", domainPrefix, projectConfig.Domain)
+	cloudRegion := projectConfig.EnabledRegion
+	// Assign the CloudSQL configuration for the Label Studio database.
+	projectConfig.CloudSQL = &cloudSQLConfig
+
+	infraComponents := infracomponents.InfraComponents{
+		CertManager:  true,
+		NginxIngress: true,
+		Domain:       domain,
+	}
+
+	serviceAccounts, err := iam.CreateIAMResources(ctx, projectConfig, LabelStudioIAM)
+	if err != nil {
+		return global.NewResourceError(application, "service accounts", err)
+	}
+	if err := configureURLSigning(ctx, projectConfig, serviceAccounts); err != nil {
+		return global.NewResourceError(application, "url signing", err)
+	}
+	gcsBucket, err := storage.CreateObjectStorage(ctx, projectConfig, bucketName)
+	if err != nil {
+		return global.NewResourceError(application, "bucket", err)
+	}
+
+	_, cloudSQLDependencies, err := cloudsql.DeployCloudSQL(ctx, projectConfig, &cloudRegion, gcpNetwork)
+	if err != nil {
+		return global.NewResourceError(application, "database", err)
+	}
+
+	kubernetesDependencies, letsEncrypt, err := createKubernetesResources(ctx, projectConfig, infraComponents, k8sProvider)
+	if err != nil {
+		return global.NewResourceError(application, "kubernetes resources", err)
+	}
+
+	if projectConfig.StageEnabled(global.StageTool) {
+		dependencies := append(kubernetesDependencies, cloudSQLDependencies...)
+		dependencies = append(dependencies, gcsBucket)
+
+		labelStudioConfig := LabelStudioConfig{
+			Domain:         domain,
+			GcsBucketName:  bucketName,
+			ServiceAccount: serviceAccounts["labelstudio"].Email,
+			LetsEncrypt:    letsEncrypt,
+		}
+		if err := deployLabelStudio(ctx, projectConfig, k8sProvider, labelStudioConfig, dependencies); err != nil {
+			return global.NewResourceError(application, "release", err)
+		}
+	}
+	ctx.Export("labelStudioURL", pulumi.Sprintf("https://%s", domain))
+	return nil
+}
+
+func deployLabelStudio(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+	labelStudioConfig LabelStudioConfig,
+	dependencies []pulumi.Resource,
+) error {
+
+	// Resolve the chart coordinates and values overlays from `helm:labelstudio`.
+	chart, err := global.ConfigureHelmChart(ctx, "labelstudio", global.HelmChartConfig{
+		Chart:   helmChart,
+		Repo:    helmChartRepo,
+		Version: helmChartVersion,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Values templates, embedded or overridden from `project:valuesDir`.
+	valuesTemplates := []string{"labelstudio/values.yaml"}
+	// The service account email and database outputs are substituted once resolved.
+	userSettings := map[string]interface{}{
+		"gcpProjectId":       projectConfig.ProjectId,
+		"hostName":           labelStudioConfig.Domain,
+		"gcsbucket":          labelStudioConfig.GcsBucketName,
+		"serviceAccount":     labelStudioConfig.ServiceAccount,
+		"dbHost":             projectConfig.CloudSQL.Connection,
+		"dbName":             projectConfig.CloudSQL.DatabaseName,
+		"dbUsername":         projectConfig.CloudSQL.User,
+		"dbPasswordSecret":   dbPasswordSecretName,
+		"whitelistedIPs":     projectConfig.WhitelistedIPs,
+		"letsEncrypt":        labelStudioConfig.LetsEncrypt,
+		"kubeServiceAccount": application,
+	}
+
+	// Behind oauth2-proxy, the ingress requires a Google login.
+	if projectConfig.OAuth2Proxy.Enabled {
+		valuesTemplates = append(valuesTemplates, "labelstudio/values-auth.yaml")
+		for key, value := range infracomponents.AuthSettings(projectConfig) {
+			userSettings[key] = value
+		}
+	}
+	// nginx serves the wildcard certificate instead of one issued for the host.
+	if projectConfig.Certificates.Wildcard {
+		valuesTemplates = append(valuesTemplates, "labelstudio/values-wildcard.yaml")
+	}
+
+	// Get the substituted values map.
+	valuesMap, err := global.GetLayeredValues(valuesTemplates, userSettings, chart.Values...)
+	if err != nil {
+		return err
+	}
+
+	resourceName := fmt.Sprintf("%s-labelstudio", projectConfig.ResourceNamePrefix)
+	_, err = helm.NewRelease(ctx, resourceName, &helm.ReleaseArgs{
+		Name:      pulumi.String(application),
+		Namespace: pulumi.String(namespace),
+		Version:   pulumi.String(chart.Version),
+		RepositoryOpts: &helm.RepositoryOptsArgs{
+			Repo: pulumi.String(chart.Repo),
+		},
+		Chart:   pulumi.String(chart.Chart),
+		Values:  valuesMap,
+		Timeout: pulumi.Int(600),
+	},
+		pulumi.DependsOn(dependencies),
+		pulumi.Provider(k8sProvider),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to deploy Label Studio Helm chart: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package labelstudio
+
+import (
+	"fmt"
+	"mlops/global"
+	"mlops/iam"
+
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/serviceaccount"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// configureURLSigning lets the Label Studio Google Service Account sign blobs as itself only, which is what
+// signing GCS URLs for the annotators' browsers needs.
+func configureURLSigning(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	serviceAccounts map[string]iam.ServiceAccountInfo,
+) error {
+
+	serviceAccount := serviceAccounts["labelstudio"]
+
+	resourceName := fmt.Sprintf("%s-labelstudio-token-creator", projectConfig.ResourceNamePrefix)
+	_, err := serviceaccount.NewIAMMember(ctx, resourceName, &serviceaccount.IAMMemberArgs{
+		ServiceAccountId: serviceAccount.ServiceAccount.Name,
+		Role:             pulumi.String("roles/iam.serviceAccountTokenCreator"),
+		Member:           pulumi.Sprintf("serviceAccount:%s", serviceAccount.Email),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to let Label Studio sign its URLs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package labelstudio
+
+import (
+	"fmt"
+	"mlops/global"
+	infracomponents "mlops/infra_components"
+
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
+	coreV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/core/v1"
+	metaV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/meta/v1"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func createKubernetesResources(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	infraComponents infracomponents.InfraComponents,
+	k8sProvider *kubernetes.Provider,
+) ([]pulumi.Resource, string, error) {
+
+	dependencies := []pulumi.Resource{}
+	ns, err := createLabelStudioNamespace(ctx, projectConfig, k8sProvider)
+	if err != nil {
+		return dependencies, "", err
+	}
+	dbSecret, err := createDatabaseSecret(ctx, projectConfig, k8sProvider, ns)
+	if err != nil {
+		return dependencies, "", err
+	}
+	dependencies, LetsEncrypt, err := infracomponents.CreateInfraComponents(ctx, projectConfig, namespace, k8sProvider, infraComponents)
+	if err != nil {
+		return dependencies, LetsEncrypt, err
+	}
+
+	dependsOn := append(dependencies,
+		dbSecret,
+	)
+	return dependsOn, LetsEncrypt, nil
+}
+
+func createLabelStudioNamespace(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+) (*coreV1.Namespace, error) {
+
+	resourceName := fmt.Sprintf("%s-labelstudio-ns", projectConfig.ResourceNamePrefix)
+	return coreV1.NewNamespace(ctx, resourceName, &coreV1.NamespaceArgs{
+		Metadata: &metaV1.ObjectMetaArgs{
+			Name: pulumi.String(namespace),
+		},
+	}, pulumi.Provider(k8sProvider))
+}
+
+// createDatabaseSecret stores the generated CloudSQL password; the chart references it by name.
+func createDatabaseSecret(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+	ns *coreV1.Namespace,
+) (*coreV1.Secret, error) {
+
+	resourceName := fmt.Sprintf("%s-labelstudio-db-secret", projectConfig.ResourceNamePrefix)
+	return coreV1.NewSecret(ctx, resourceName, &coreV1.SecretArgs{
+		Metadata: &metaV1.ObjectMetaArgs{
+			Namespace: ns.Metadata.Name(),
+			Name:      pulumi.String(dbPasswordSecretName),
+		},
+		Type: pulumi.String("Opaque"),
+		StringData: pulumi.StringMap{
+			"password": projectConfig.CloudSQL.Password,
+		},
+	},
+		pulumi.Provider(k8sProvider),
+		pulumi.DependsOn([]pulumi.Resource{ns}),
+	)
+}
@@ -0,0 +1,12 @@
+package labelstudio
+
+import (
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type LabelStudioConfig struct {
+	Domain         string
+	GcsBucketName  string
+	ServiceAccount pulumi.StringOutput
+	LetsEncrypt    string
+}
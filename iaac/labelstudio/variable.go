@@ -0,0 +1,40 @@
+package labelstudio
+
+import (
+	"mlops/global"
+	"mlops/iam"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var (
+	dbPasswordSecretName = "labelstudio-db-credentials"
+
+	// Object permissions on the bucket; signing the GCS URLs for the annotators' browsers is granted on the
+	// Service Account itself ( `configureURLSigning` ).
+	LabelStudioIAM = map[string]iam.IAM{
+		"labelstudio": {
+			ResourceNamePrefix: "ls",
+			DisplayName:        "Label Studio Storage",
+			Permissions: pulumi.StringArray{
+				pulumi.String("storage.buckets.get"),
+				pulumi.String("storage.objects.create"),
+				pulumi.String("storage.objects.delete"),
+				pulumi.String("storage.objects.get"),
+				pulumi.String("storage.objects.list"),
+				pulumi.String("storage.objects.update"),
+			},
+			CreateRole:           true,
+			CreateServiceAccount: true,
+			WorkloadIdentityBinding: []string{
+				"labelstudio/labelstudio",
+			},
+		},
+	}
+
+	cloudSQLConfig = global.CloudSQLConfig{
+		User:               "labelstudio",
+		Database:           "labelstudio",
+		InstancePrefixName: "labelstudio",
+	}
+)
@@ -1,85 +1,140 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 
 	"mlops/autoneg"
 	"mlops/gke"
 	"mlops/global"
+	infracomponents "mlops/infra_components"
 	"mlops/ml"
 	"mlops/storage"
 	"mlops/vpc"
 
-	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/serviceaccount"
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/compute"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
 )
 
 func main() {
 	pulumi.Run(func(ctx *pulumi.Context) error {
-		projectConfig := global.GenerateProjectConfig(ctx)
-		global.EnableGCPServices(ctx, projectConfig)
+		projectConfig, err := global.GenerateProjectConfig(ctx)
+		if err != nil {
+			return abortDeployment(global.NewResourceError("project", "configuration", err))
+		}
+		// Every template is checked, not only those the stack renders, so an overlay cannot fail once enabled.
+		if err := global.ValidateTemplates(); err != nil {
+			return abortDeployment(global.NewResourceError("project", "values templates", err))
+		}
+		if _, err := global.EnableGCPServices(ctx, projectConfig); err != nil {
+			return abortDeployment(err)
+		}
 
 		if config.GetBool(ctx, "storage:create") {
 			bucketNames := global.FormatStringIntoList(config.Get(ctx, "storage:bucketNames"))
 			for _, bucketName := range bucketNames {
-				storage.CreateObjectStorage(ctx, projectConfig, bucketName)
+				if _, err := storage.CreateObjectStorage(ctx, projectConfig, bucketName); err != nil {
+					return abortDeployment(err)
+				}
 			}
 		}
 
 		if err := CreateProjectResources(ctx, projectConfig); err != nil {
-			return fmt.Errorf("failed to create Project resources end-to-end: %w", err)
+			return abortDeployment(err)
 		}
 		return nil
 	})
 }
 
+// abortDeployment stops the deployment on its first failure, naming the innermost subsystem and resource it
+// happened on ahead of the full error chain.
+func abortDeployment(err error) error {
+	failedOn := "unknown resource"
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if resourceErr, ok := e.(*global.ResourceError); ok {
+			failedOn = fmt.Sprintf("%s ( %s )", resourceErr.Subsystem, resourceErr.Resource)
+		}
+	}
+	return fmt.Errorf("deployment aborted, %s failed: %w", failedOn, err)
+}
+
 func CreateProjectResources(ctx *pulumi.Context, projectConfig global.ProjectConfig) error {
 	// -------------------------- VPC -----------------------------
 	gcpNetwork, err := vpc.CreateVPCResources(ctx, projectConfig)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	gcpSubnetwork, err := vpc.CreateVPCSubnetResources(ctx, projectConfig, gcpNetwork.ID())
 	if err != nil {
 		return err
 	}
+	if !projectConfig.StageEnabled(global.StageCluster) {
+		return nil
+	}
 	// --------------------------- GKE ----------------------------
-	k8sProvider, NodePool, err := gke.CreateGKEResources(ctx, projectConfig, gcpNetwork.ID(), gcpSubnetwork.ID())
+	k8sProvider, nodeZones, err := gke.CreateGKEResources(ctx, projectConfig, gcpNetwork.ID(), gcpSubnetwork.ID())
 	if err != nil {
 		return err
 	}
 
-	var negServiceAccount *serviceaccount.Account
-	if config.GetBool(ctx, "vpc.autoNEG") {
-		negServiceAccount, err = autoneg.EnableAutoNEGController(ctx, projectConfig, k8sProvider)
+	// The backend service waits for the AutoNEG controller to be ready.
+	var negController pulumi.Resource
+	if projectConfig.LoadBalancer.AutoNEG {
+		negController, err = autoneg.EnableAutoNEGController(ctx, projectConfig, k8sProvider)
 		if err != nil {
 			return err
 		}
 	}
-	if config.GetBool(ctx, "vpc:loadBalancer") {
-		// If AutoNEG is enabled, defer Load Balancer creation until it's ready
-		if negServiceAccount != nil {
-			negServiceAccount.ID().ApplyT(func(_ string) error {
-				_, err := vpc.CreateBackendServiceResources(ctx, projectConfig)
-				return err
-			})
-		} else {
-			// If AutoNEG is not enabled, create Load Balancer immediately
-			_, err := vpc.CreateBackendServiceResources(ctx, projectConfig)
-			if err != nil {
-				return err
-			}
+	// The address is reserved ahead of the tools, so their hostnames are recorded on it.
+	var gcpGlobalAddress *compute.GlobalAddress
+	if projectConfig.LoadBalancer.Enabled {
+		gcpGlobalAddress, err = vpc.CreateLoadBalancerAddress(ctx, projectConfig)
+		if err != nil {
+			return err
 		}
+		projectConfig.LoadBalancer.Address = gcpGlobalAddress.Address
 	}
 
-	NodePool.ID().ApplyT(func(_ string) error {
-		target := config.Get(ctx, "project:target")
-		if err = ml.TargetMLOpTool(ctx, target, projectConfig, k8sProvider, gcpNetwork); err != nil {
-			return err
-		}
+	if !projectConfig.StageEnabled(global.StagePlatform) {
 		return nil
-	})
+	}
+
+	// The Kubernetes provider waits for the node pools, so the tools are registered directly.
+	target := config.Get(ctx, "project:target")
+	if err = ml.TargetMLOpTool(ctx, target, projectConfig, k8sProvider, gcpNetwork); err != nil {
+		return err
+	}
+	if err = ml.DeployAddons(ctx, projectConfig, k8sProvider, gcpNetwork); err != nil {
+		return err
+	}
+	if projectConfig.LoadBalancer.Enabled {
+		return createLoadBalancer(ctx, projectConfig, gcpGlobalAddress, nodeZones, negController)
+	}
 	return nil
 }
+
+// createLoadBalancer routes the Global Load Balancer to the NEGs of the nginx controller the tools are exposed through,
+// in the zones of the nodes, for the hostnames of the deployed tools.
+func createLoadBalancer(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	gcpGlobalAddress *compute.GlobalAddress,
+	negZones pulumi.StringArrayInput,
+	negController pulumi.Resource,
+) error {
+
+	nginxController := infracomponents.NginxController()
+	if nginxController == nil {
+		ctx.Log.Warn("The Global Load Balancer routes to the nginx ingress controller, which no deployed tool installs; skipping its backend.", nil)
+		return nil
+	}
+	// The NEGs are created by GKE once the controller Service exists.
+	dependencies := []pulumi.Resource{nginxController}
+	if negController != nil {
+		dependencies = append(dependencies, negController)
+	}
+	_, err := vpc.CreateBackendServiceResources(ctx, projectConfig, gcpGlobalAddress, infracomponents.Hosts(), negZones, pulumi.DependsOn(dependencies))
+	return err
+}
@@ -4,6 +4,8 @@ import (
 	"mlops/flux"
 	"mlops/flyte"
 	"mlops/global"
+	"mlops/jupyterhub"
+	"mlops/labelstudio"
 	"mlops/mlrun"
 
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/compute"
@@ -20,7 +22,8 @@ func TargetMLOpTool(
 ) error {
 
 	var err error
-	if target == "kubeflow" {
+	// Flux deploys Kubeflow itself, so it belongs to the tool stage.
+	if target == "kubeflow" && projectConfig.StageEnabled(global.StageTool) {
 		if err = flux.DeployFlux(ctx, k8sProvider); err != nil {
 			return err
 		}
@@ -37,3 +40,26 @@ func TargetMLOpTool(
 	}
 	return nil
 }
+
+// DeployAddons deploys the add-ons selected through `project:addons` alongside the MLOps target.
+func DeployAddons(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+	gcpNetwork *compute.Network,
+) error {
+
+	for _, addon := range projectConfig.Addons {
+		if addon == "jupyterhub" {
+			if err := jupyterhub.CreateJupyterHubResources(ctx, projectConfig, k8sProvider); err != nil {
+				return err
+			}
+		}
+		if addon == "labelstudio" {
+			if err := labelstudio.CreateLabelStudioResources(ctx, projectConfig, k8sProvider, gcpNetwork); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package mlrun
 
 import (
 	"fmt"
+	"mlops/cloudsql"
 	"mlops/global"
 	"mlops/iam"
 	infracomponents "mlops/infra_components"
@@ -12,11 +13,10 @@ import (
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/helm/v3"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
 )
 
 var (
-	deploy = true
-
 	application      = "mlrun"
 	domainPrefix     = "mlrun"
 	namespace        = "mlrun"
@@ -37,14 +37,23 @@ func CreateMLRunResources(
 	registryEndpoint := fmt.Sprintf("%s-docker.pkg.dev", cloudRegion.Region)
 	registryURL := fmt.Sprintf("%s/%s/%s", registryEndpoint, projectConfig.ProjectId, registryName)
 	domain := fmt.Sprintf("%s.%s", domainPrefix, projectConfig.Domain)
+	backend, err := configureBackend(ctx)
+	if err != nil {
+		return global.NewResourceError(application, "configuration", err)
+	}
+	ingresses, err := configureIngresses(ctx, backend)
+	if err != nil {
+		return global.NewResourceError(application, "configuration", err)
+	}
 
+	// Every UI gets its own host through its Ingress.
 	infraComponents := infracomponents.InfraComponents{
-		CertManager:       true,
-		NginxIngress:      true,
-		CertManagerIssuer: true,
-		Certificate:       true,
-		Domain:            domain,
-		Ingress:           false,
+		CertManager:  true,
+		NginxIngress: true,
+		Certificate:  false,
+		Domain:       domain,
+		Ingress:      true,
+		IngressMap:   ingresses,
 	}
 	artifactRegistryConfig := global.ArtifactRegistryConfig{
 		RegistryName: registryName,
@@ -52,63 +61,176 @@ func CreateMLRunResources(
 
 	registry, err := registry.CreateArtifactRegistry(ctx, projectConfig, artifactRegistryConfig, pulumi.DependsOn([]pulumi.Resource{}))
 	if err != nil {
-		return err
+		return global.NewResourceError(application, "artifact registry", err)
 	}
-	serviceAccounts, err := iam.CreateIAMResources(ctx, projectConfig, MLRunIAM)
-	if err != nil {
-		return err
+	// In keyless mode the key is only kept when Kaniko/Nuclio builders need push credentials.
+	builderKey := !projectConfig.ArtifactRegistry.Keyless || projectConfig.ArtifactRegistry.BuilderKey
+	mlrunIAM := MLRunIAM
+	if !builderKey {
+		mlrunIAM = iam.WithoutKeys(MLRunIAM)
 	}
-	err = createDockerRegistrySecret(ctx, projectConfig, serviceAccounts, registry, registryURL, k8sProvider)
+	serviceAccounts, err := iam.CreateIAMResources(ctx, projectConfig, mlrunIAM)
 	if err != nil {
-		return err
+		return global.NewResourceError(application, "service accounts", err)
+	}
+	if projectConfig.ArtifactRegistry.Keyless {
+		if err = grantRegistryReaders(ctx, projectConfig, registry); err != nil {
+			return global.NewResourceError(application, "registry readers", err)
+		}
+	}
+	secretName := ""
+	if builderKey {
+		err = createDockerRegistrySecret(ctx, projectConfig, serviceAccounts, registry, registryURL, k8sProvider)
+		if err != nil {
+			return global.NewResourceError(application, "registry secret", err)
+		}
+		secretName = registrySecretName
+	} else {
+		ctx.Log.Warn("MLRun registry has no push credentials; set `registry:builderKey` for in-cluster Kaniko/Nuclio builds.", nil)
 	}
 
-	gcsBucket := storage.CreateObjectStorage(ctx, projectConfig, bucketName)
-	dependencies, LetsEncrypt, err := createKubernetesResources(ctx, projectConfig, infraComponents, k8sProvider)
+	gcsBucket, err := storage.CreateObjectStorage(ctx, projectConfig, bucketName)
 	if err != nil {
-		return err
+		return global.NewResourceError(application, "bucket", err)
+	}
+	dependencies, LetsEncrypt, passwords, err := createKubernetesResources(ctx, projectConfig, infraComponents, k8sProvider)
+	if err != nil {
+		return global.NewResourceError(application, "kubernetes resources", err)
 	}
 
 	dependencies = append(dependencies, gcsBucket)
 	MLRunConfig := MLRunConfig{
 		RegistryURL:        registryURL,
 		GcsBucketName:      bucketName,
-		RegistrySecretName: registrySecretName,
+		RegistrySecretName: secretName,
 		Domain:             domain,
 		LetsEncrypt:        LetsEncrypt,
+		Backend:            backend,
+		MinioPassword:      passwords["minio"],
 	}
-	if deploy {
-		if err = deployMLRun(ctx, projectConfig, k8sProvider, MLRunConfig, dependencies); err != nil {
-			return err
+
+	// The `gcp` backend keeps MLRun's state in CloudSQL and GCS instead of the bundled MySQL and MinIO.
+	if MLRunConfig.Backend == backendGCP {
+		if err = configureWorkloadIdentity(ctx, projectConfig, serviceAccounts, k8sProvider, dependencies); err != nil {
+			return global.NewResourceError(application, "workload identity", err)
+		}
+		projectConfig.CloudSQL = &cloudSQLConfig
+		_, cloudSQLDependencies, err := cloudsql.DeployCloudSQL(ctx, projectConfig, &cloudRegion, gcpNetwork)
+		if err != nil {
+			return global.NewResourceError(application, "database", err)
+		}
+		dependencies = append(dependencies, cloudSQLDependencies...)
+	}
+
+	if projectConfig.StageEnabled(global.StageTool) {
+		// The CloudSQL outputs are substituted once resolved.
+		var dbSettings map[string]interface{}
+		if MLRunConfig.Backend == backendGCP {
+			dbSettings = map[string]interface{}{
+				"dbHost":     projectConfig.CloudSQL.Connection,
+				"dbPassword": projectConfig.CloudSQL.Password,
+				"dbName":     projectConfig.CloudSQL.DatabaseName,
+				"dbUsername": projectConfig.CloudSQL.User,
+			}
+		}
+		if err = deployMLRun(ctx, projectConfig, k8sProvider, MLRunConfig, dbSettings, dependencies); err != nil {
+			return global.NewResourceError(application, "release", err)
 		}
 	}
 
 	return nil
 }
 
+// configureBackend reads `mlrun:backend`, defaulting to the chart's bundled services.
+func configureBackend(
+	ctx *pulumi.Context,
+) (string, error) {
+
+	backend := config.Get(ctx, "mlrun:backend")
+	switch backend {
+	case "":
+		return backendBundled, nil
+	case backendBundled, backendGCP:
+		return backend, nil
+	default:
+		return "", fmt.Errorf("MLRun backend '%s' is not supported; use `%s` or `%s`", backend, backendBundled, backendGCP)
+	}
+}
+
+// configureIngresses returns the Ingresses of the MLRun UIs, dropping those disabled in `mlrun:ingresses`
+// ( e.g. `minio: false` ) and those of the services the backend does not deploy.
+func configureIngresses(
+	ctx *pulumi.Context,
+	backend string,
+) (map[string]infracomponents.IngressConfig, error) {
+
+	toggles := map[string]bool{}
+	if err := config.GetObject(ctx, "mlrun:ingresses", &toggles); err != nil {
+		return nil, fmt.Errorf("failed to parse `mlrun:ingresses`: %w", err)
+	}
+	for service := range toggles {
+		if _, ok := ingressMap[service]; !ok {
+			return nil, fmt.Errorf("MLRun Ingress '%s' is not supported; `mlrun:ingresses` accepts ui, jupyter, grafana, minio, pipelines and nuclio", service)
+		}
+	}
+
+	ingresses := map[string]infracomponents.IngressConfig{}
+	for service, ingress := range ingressMap {
+		if enabled, ok := toggles[service]; ok && !enabled {
+			continue
+		}
+		if backend == backendGCP && bundledOnlyIngresses[service] {
+			continue
+		}
+		ingresses[service] = ingress
+	}
+	return ingresses, nil
+}
+
 func deployMLRun(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	k8sProvider *kubernetes.Provider,
 	MLRunConfig MLRunConfig,
+	dbSettings map[string]interface{},
 	dependencies []pulumi.Resource,
 ) error {
 
-	// Path to the values.yaml file.
-	valuesFilePath := "../helm/mlrun/values/values.yaml"
+	// Resolve the chart coordinates and values overlays from `helm:mlrun`.
+	chart, err := global.ConfigureHelmChart(ctx, "mlrun", global.HelmChartConfig{
+		Chart:   helmChart,
+		Repo:    helmChartRepo,
+		Version: helmChartVersion,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Values templates, embedded or overridden from `project:valuesDir`; the backend template is layered on top.
+	valuesTemplates := []string{"mlrun/values.yaml"}
+	if MLRunConfig.Backend == backendGCP {
+		valuesTemplates = append(valuesTemplates, "mlrun/values-gcp.yaml")
+	}
 	// Build the replacement map using resolved strings.
 	userSettings := map[string]interface{}{
-		"gcsbucket":          MLRunConfig.GcsBucketName,
-		"hostName":           MLRunConfig.Domain,
-		"registryURL":        MLRunConfig.RegistryURL,
-		"registrySecretName": MLRunConfig.RegistrySecretName,
-		"whitelistedIPs":     projectConfig.WhitelistedIPs,
-		"minioRootPassword":  "minio123",
-		"letsEncrypt":        MLRunConfig.LetsEncrypt,
+		"gcsbucket":              MLRunConfig.GcsBucketName,
+		"hostName":               MLRunConfig.Domain,
+		"registryURL":            MLRunConfig.RegistryURL,
+		"registrySecretName":     MLRunConfig.RegistrySecretName,
+		"minioSecretName":        generatedCredentials["minio"].SecretName,
+		"minioUser":              generatedCredentials["minio"].Fields["rootUser"],
+		"minioPipelinesPassword": MLRunConfig.MinioPassword,
+		"grafanaSecretName":      generatedCredentials["grafana"].SecretName,
+		"jupyterSecretName":      generatedCredentials["jupyter"].SecretName,
+		"workloadServiceAccount": workloadServiceAccountName,
+	}
+
+	for key, value := range dbSettings {
+		userSettings[key] = value
 	}
 
 	// Get the substituted values map.
-	valuesMap, err := global.GetValues(valuesFilePath, userSettings)
+	valuesMap, err := global.GetLayeredValues(valuesTemplates, userSettings, chart.Values...)
 	if err != nil {
 		return err
 	}
@@ -118,11 +240,11 @@ func deployMLRun(
 	_, err = helm.NewRelease(ctx, resourceName, &helm.ReleaseArgs{
 		Name:      pulumi.String(application),
 		Namespace: pulumi.String(namespace),
-		Version:   pulumi.String(helmChartVersion),
+		Version:   pulumi.String(chart.Version),
 		RepositoryOpts: &helm.RepositoryOptsArgs{
-			Repo: pulumi.String(helmChartRepo),
+			Repo: pulumi.String(chart.Repo),
 		},
-		Chart:   pulumi.String(helmChart),
+		Chart:   pulumi.String(chart.Chart),
 		Values:  valuesMap,
 		Timeout: pulumi.Int(600),
 	},
@@ -0,0 +1,54 @@
+package mlrun
+
+import (
+	"fmt"
+	"mlops/global"
+	"mlops/iam"
+
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/serviceaccount"
+	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
+	coreV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/core/v1"
+	metaV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/meta/v1"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// configureWorkloadIdentity binds the function pods' Kubernetes Service Account to the `mlrun` Google Service Account,
+// so artifacts are written to the GCS bucket without keys.
+func configureWorkloadIdentity(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	serviceAccounts map[string]iam.ServiceAccountInfo,
+	k8sProvider *kubernetes.Provider,
+	dependencies []pulumi.Resource,
+) error {
+
+	serviceAccount := serviceAccounts["mlrun"]
+
+	resourceName := fmt.Sprintf("%s-mlrun-workload-identity", projectConfig.ResourceNamePrefix)
+	_, err := serviceaccount.NewIAMMember(ctx, resourceName, &serviceaccount.IAMMemberArgs{
+		ServiceAccountId: serviceAccount.ServiceAccount.Name,
+		Role:             pulumi.String("roles/iam.workloadIdentityUser"),
+		Member:           pulumi.Sprintf("serviceAccount:%s.svc.id.goog[%s/%s]", projectConfig.ProjectId, namespace, workloadServiceAccountName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to bind MLRun Workload Identity: %w", err)
+	}
+
+	resourceName = fmt.Sprintf("%s-mlrun-%s-sa-patch", projectConfig.ResourceNamePrefix, workloadServiceAccountName)
+	_, err = coreV1.NewServiceAccountPatch(ctx, resourceName, &coreV1.ServiceAccountPatchArgs{
+		Metadata: &metaV1.ObjectMetaPatchArgs{
+			Name:      pulumi.String(workloadServiceAccountName),
+			Namespace: pulumi.String(namespace),
+			Annotations: pulumi.StringMap{
+				"iam.gke.io/gcp-service-account": serviceAccount.Email,
+			},
+		},
+	},
+		pulumi.DependsOn(dependencies),
+		pulumi.Provider(k8sProvider),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to annotate MLRun service account: %w", err)
+	}
+	return nil
+}
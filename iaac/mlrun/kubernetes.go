@@ -8,6 +8,7 @@ import (
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
 	coreV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/core/v1"
 	metaV1 "github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes/meta/v1"
+	"github.com/pulumi/pulumi-random/sdk/v4/go/random"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
@@ -16,19 +17,75 @@ func createKubernetesResources(
 	projectConfig global.ProjectConfig,
 	infraComponents infracomponents.InfraComponents,
 	k8sProvider *kubernetes.Provider,
-) ([]pulumi.Resource, string, error) {
+) ([]pulumi.Resource, string, map[string]pulumi.StringOutput, error) {
 
 	dependencies := []pulumi.Resource{}
-	_, err := createMLRunNamespace(ctx, projectConfig, k8sProvider)
+	mlrunNamespace, err := createMLRunNamespace(ctx, projectConfig, k8sProvider)
 	if err != nil {
-		return dependencies, "", err
+		return dependencies, "", nil, err
 	}
+	credentialSecrets, passwords, err := createGeneratedCredentials(ctx, projectConfig, k8sProvider, mlrunNamespace)
+	if err != nil {
+		return dependencies, "", nil, err
+	}
+	infraComponents.DependsOn = append(infraComponents.DependsOn, mlrunNamespace)
 	dependencies, LetsEncrypt, err := infracomponents.CreateInfraComponents(ctx, projectConfig, namespace, k8sProvider, infraComponents)
 	if err != nil {
-		return dependencies, LetsEncrypt, err
+		return dependencies, LetsEncrypt, nil, err
 	}
 
-	return dependencies, LetsEncrypt, nil
+	return append(dependencies, credentialSecrets...), LetsEncrypt, passwords, nil
+}
+
+// createGeneratedCredentials generates the passwords of the bundled services and stores them in the Secrets
+// the chart references, so no deployment shares a known credential. Passwords are only exported as secrets, and
+// returned per service for the clients of the chart that cannot read them from a Secret.
+func createGeneratedCredentials(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	k8sProvider *kubernetes.Provider,
+	mlrunNamespace *coreV1.Namespace,
+) ([]pulumi.Resource, map[string]pulumi.StringOutput, error) {
+
+	var secrets []pulumi.Resource
+	passwords := make(map[string]pulumi.StringOutput)
+	for service, credential := range generatedCredentials {
+		resourceName := fmt.Sprintf("%s-mlrun-%s-password", projectConfig.ResourceNamePrefix, service)
+		password, err := random.NewRandomPassword(ctx, resourceName, &random.RandomPasswordArgs{
+			Length:  pulumi.Int(24),
+			Special: pulumi.Bool(false),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		passwords[service] = password.Result
+
+		secretData := pulumi.StringMap{}
+		for _, key := range credential.PasswordKeys {
+			secretData[key] = password.Result
+		}
+		for key, value := range credential.Fields {
+			secretData[key] = pulumi.String(value)
+		}
+		resourceName = fmt.Sprintf("%s-mlrun-%s-credentials", projectConfig.ResourceNamePrefix, service)
+		secret, err := coreV1.NewSecret(ctx, resourceName, &coreV1.SecretArgs{
+			Metadata: &metaV1.ObjectMetaArgs{
+				Name:      pulumi.String(credential.SecretName),
+				Namespace: mlrunNamespace.Metadata.Name(),
+			},
+			StringData: secretData,
+		},
+			pulumi.Provider(k8sProvider),
+			pulumi.DependsOn([]pulumi.Resource{mlrunNamespace}),
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create MLRun %s credentials: %w", service, err)
+		}
+		secrets = append(secrets, secret)
+
+		ctx.Export(credential.Export, pulumi.ToSecret(password.Result))
+	}
+	return secrets, passwords, nil
 }
 
 func createMLRunNamespace(
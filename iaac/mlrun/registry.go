@@ -4,8 +4,10 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"mlops/gke"
 	"mlops/global"
 	"mlops/iam"
+	"mlops/registry"
 
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/artifactregistry"
 	"github.com/pulumi/pulumi-kubernetes/sdk/v4/go/kubernetes"
@@ -14,6 +16,18 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
+// grantRegistryReaders gives the node pools read access to the MLRun repository,
+// so function images are pulled without a key secret.
+func grantRegistryReaders(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	repository *artifactregistry.Repository,
+) error {
+
+	nodeMember := pulumi.Sprintf("serviceAccount:%s", gke.NodeServiceAccountEmail(projectConfig))
+	return registry.GrantRepositoryReader(ctx, projectConfig, repository, "mlrun-nodes", nodeMember)
+}
+
 // createDockerRegistrySecret constructs the required Docker config JSON using
 // the provided service account key JSON and creates a Kubernetes secret.
 // The secret is of type "kubernetes.io/dockerconfigjson".
@@ -1,5 +1,16 @@
 package mlrun
 
+import "github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+// generatedCredential describes a Kubernetes Secret holding a generated password, under each of its password keys,
+// and its fixed fields; the password is exported as a secret output.
+type generatedCredential struct {
+	SecretName   string
+	PasswordKeys []string
+	Fields       map[string]string
+	Export       string
+}
+
 type MLRunConfig struct {
 	RegistryEndpoint   string
 	RegistryURL        string
@@ -7,4 +18,6 @@ type MLRunConfig struct {
 	RegistrySecretName string
 	Domain             string
 	LetsEncrypt        string
+	Backend            string
+	MinioPassword      pulumi.StringOutput // generated root password, for the Pipelines artifact store only
 }
@@ -1,12 +1,49 @@
 package mlrun
 
 import (
+	"mlops/global"
 	"mlops/iam"
 	infracomponents "mlops/infra_components"
 )
 
 var (
 	registrySecretName = "gcr-registry-credentials"
+
+	// `mlrun:backend` values: the chart's own MinIO and MySQL, or GCS and CloudSQL.
+	backendBundled = "bundled"
+	backendGCP     = "gcp"
+
+	// Kubernetes Service Account of the MLRun function pods, bound to the `mlrun` GSA in the `gcp` backend.
+	workloadServiceAccountName = "default"
+
+	cloudSQLConfig = global.CloudSQLConfig{
+		User:               "mlrun",
+		Database:           "mlrun",
+		InstancePrefixName: "mlrun",
+		DatabaseVersion:    "MYSQL_8_0",
+	}
+
+	// Bundled services whose credentials are generated and kept in Kubernetes Secrets referenced by the chart.
+	generatedCredentials = map[string]generatedCredential{
+		// Read by MinIO ( `rootUser`, `rootPassword` ) and by the MLRun API and function pods ( `AWS_*` ).
+		"minio": {
+			SecretName:   "mlrun-minio-credentials",
+			PasswordKeys: []string{"rootPassword", "AWS_SECRET_ACCESS_KEY"},
+			Fields:       map[string]string{"rootUser": "minio", "AWS_ACCESS_KEY_ID": "minio"},
+			Export:       "mlrunMinioPassword",
+		},
+		"grafana": {
+			SecretName:   "mlrun-grafana-credentials",
+			PasswordKeys: []string{"admin-password"},
+			Fields:       map[string]string{"admin-user": "admin"},
+			Export:       "mlrunGrafanaPassword",
+		},
+		"jupyter": {
+			SecretName:   "mlrun-jupyter-credentials",
+			PasswordKeys: []string{"token"},
+			Export:       "mlrunJupyterPassword",
+		},
+	}
 	// serviceAccountSecretName = "mlrun-sa-credentials"
 
 	MLRunIAM = map[string]iam.IAM{
@@ -23,51 +60,64 @@ var (
 			CreateKey:            true,
 		},
 	}
-	// Define a map of Ingress configurations for your components.
+	// Ingress of each MLRun UI, served at `<DNS>.<domain>` and toggled through `mlrun:ingresses`.
 	ingressMap = map[string]infracomponents.IngressConfig{
+		"ui": {
+			DNS: "mlrun",
+			Paths: []infracomponents.IngressPathConfig{
+				{
+					Service: "mlrun-ui",
+					Port:    80,
+				},
+			},
+		},
+		"jupyter": {
+			DNS: "mlrun-jupyter",
+			Paths: []infracomponents.IngressPathConfig{
+				{
+					Service: "mlrun-jupyter",
+					Port:    8888,
+				},
+			},
+		},
 		"grafana": {
-			DNS: "grafana",
+			DNS: "mlrun-grafana",
 			Paths: []infracomponents.IngressPathConfig{
 				{
 					Service: "grafana",
-					Port:    3000,
+					Port:    80,
 				},
 			},
 		},
 		"minio": {
-			DNS: "minio",
+			DNS: "mlrun-minio",
 			Paths: []infracomponents.IngressPathConfig{
 				{
-					Service: "minio",
+					Service: "minio-console",
 					Port:    9001,
 				},
 			},
 		},
-		"mlrun": {
-			DNS: "mlrun", // using the base domain for central UI
+		"pipelines": {
+			DNS: "mlrun-pipelines",
 			Paths: []infracomponents.IngressPathConfig{
 				{
-					Path:    "pipelines",
 					Service: "ml-pipeline-ui",
-					Port:    3000,
-				},
-				{
-					// Path:    "lab",
-					Service: "mlrun-jupyter",
-					Port:    8888,
-				},
-				{
-					// No path provided means the root path for the central UI.
-					Path:    "central",
-					Service: "mlrun-ui",
 					Port:    80,
 				},
+			},
+		},
+		"nuclio": {
+			DNS: "mlrun-nuclio",
+			Paths: []infracomponents.IngressPathConfig{
 				{
-					Path:    "nuclio",
 					Service: "nuclio-dashboard",
 					Port:    8070,
 				},
 			},
 		},
 	}
+
+	// Services the `gcp` backend disables, so their Ingresses would have no backend.
+	bundledOnlyIngresses = map[string]bool{"minio": true, "pipelines": true}
 )
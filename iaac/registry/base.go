@@ -1,7 +1,6 @@
 package registry
 
 import (
-	"fmt"
 	"mlops/global"
 
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/artifactregistry"
@@ -19,49 +18,47 @@ func CreateArtifactRegistry(
 	artifactRegistry = global.ConfigureArtifactRegistry(ctx, artifactRegistry)
 	registry, err := createRegistry(ctx, projectConfig, artifactRegistry, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Artifact Registry: %w", err)
+		return nil, global.NewResourceError("registry", artifactRegistry.RegistryName, err)
 	}
 
-	registry.ID().ApplyT(func(_ string) error {
-		if artifactRegistry.GithubServiceAccountCreate {
-			// Create a Workload Identity Pool
-			randomString := global.GenerateRandomString(4)
-			wifPool, err := createWorkloadIdentityPool(ctx, projectConfig, artifactRegistry, randomString)
-			if err != nil {
-				return fmt.Errorf("failed to create Workload Identity Pool: %w", err)
-			}
-			wifProvider, err := createWorkloadIdentityPoolProvider(ctx, projectConfig, artifactRegistry, wifPool)
-			if err != nil {
-				return fmt.Errorf("failed to create Workload Identity Provider: %w", err)
-			}
-
-			// Create a Service Account
-			githubServiceAccount, serviceAccountMember, err := createGithubServiceAccount(ctx, projectConfig, artifactRegistry)
-			if err != nil {
-				return fmt.Errorf("failed to create Service Account: %w", err)
-			}
-			err = createGithubServiceAccountIAMBinding(ctx, projectConfig, artifactRegistry, githubServiceAccount.ID(), wifPool)
-			if err != nil {
-				return fmt.Errorf("failed to bind IAM role to Service Account: %w", err)
-			}
-			err = createRegistryIAMMember(ctx, projectConfig, artifactRegistry, githubServiceAccount, serviceAccountMember)
-			if err != nil {
-				return fmt.Errorf("failed to assign Artifact Registry writer role: %w", err)
-			}
+	// The federation and Service Accounts are only created for the registry.
+	registryDependency := pulumi.DependsOn([]pulumi.Resource{registry})
+	if artifactRegistry.GithubServiceAccountCreate {
+		// Create a Workload Identity Pool
+		wifPool, err := createWorkloadIdentityPool(ctx, projectConfig, artifactRegistry, registryDependency)
+		if err != nil {
+			return nil, global.NewResourceError("registry", "github workload identity pool", err)
+		}
+		wifProvider, err := createWorkloadIdentityPoolProvider(ctx, projectConfig, artifactRegistry, wifPool)
+		if err != nil {
+			return nil, global.NewResourceError("registry", "github workload identity provider", err)
+		}
 
-			ctx.Export("workloadIdentityProvider", wifProvider.Name)
-			ctx.Export("githubServiceAccountEmail", githubServiceAccount.Email)
+		// Create a Service Account
+		githubServiceAccount, serviceAccountMember, err := createGithubServiceAccount(ctx, projectConfig, artifactRegistry)
+		if err != nil {
+			return nil, global.NewResourceError("registry", "github service account", err)
+		}
+		err = createGithubServiceAccountIAMBinding(ctx, projectConfig, artifactRegistry, githubServiceAccount.ID(), wifPool)
+		if err != nil {
+			return nil, global.NewResourceError("registry", "github service account binding", err)
 		}
+		err = createRegistryIAMMember(ctx, projectConfig, artifactRegistry, githubServiceAccount, serviceAccountMember)
+		if err != nil {
+			return nil, global.NewResourceError("registry", "writer role", err)
+		}
+
+		ctx.Export("workloadIdentityProvider", wifProvider.Name)
+		ctx.Export("githubServiceAccountEmail", githubServiceAccount.Email)
+	}
 
-		if artifactRegistry.ContinuousDevelopmentServiceAccountCreate {
-			cdServiveAccount, err := createRegistryServiceAccount(ctx, projectConfig, artifactRegistry)
-			if err != nil {
-				return err
-			}
-			ctx.Export("cdServiveAccountEmail", cdServiveAccount.Email)
+	if artifactRegistry.ContinuousDevelopmentServiceAccountCreate {
+		cdServiveAccount, err := createRegistryServiceAccount(ctx, projectConfig, artifactRegistry)
+		if err != nil {
+			return nil, global.NewResourceError("registry", "continuous development service account", err)
 		}
-		ctx.Export("artifactRegistryURL", registry.RepositoryId)
-		return nil
-	})
+		ctx.Export("cdServiveAccountEmail", cdServiveAccount.Email)
+	}
+	ctx.Export("artifactRegistryURL", registry.RepositoryId)
 	return registry, nil
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/iam"
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/projects"
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/serviceaccount"
+	"github.com/pulumi/pulumi-random/sdk/v4/go/random"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
@@ -83,19 +84,30 @@ func createWorkloadIdentityPool(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	artifactRegistry global.ArtifactRegistryConfig,
-	randomString string,
+	opts ...pulumi.ResourceOption,
 ) (*iam.WorkloadIdentityPool, error) {
 
 	formattedName := strings.Title(strings.ReplaceAll(artifactRegistry.RegistryName, "-", " "))
 
-	resourceName := fmt.Sprintf("%s-%s-github-wip", projectConfig.ResourceNamePrefix, artifactRegistry.RegistryName)
+	// A deleted pool ID stays reserved for 30 days, so the ID carries a suffix kept in the stack state.
+	resourceName := fmt.Sprintf("%s-%s-github-wip-suffix", projectConfig.ResourceNamePrefix, artifactRegistry.RegistryName)
+	poolSuffix, err := random.NewRandomString(ctx, resourceName, &random.RandomStringArgs{
+		Length:  pulumi.Int(4),
+		Special: pulumi.Bool(false),
+		Upper:   pulumi.Bool(false),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resourceName = fmt.Sprintf("%s-%s-github-wip", projectConfig.ResourceNamePrefix, artifactRegistry.RegistryName)
 	wifPool, err := iam.NewWorkloadIdentityPool(ctx, resourceName, &iam.WorkloadIdentityPoolArgs{
 		Project:                pulumi.String(projectConfig.ProjectId),
 		Description:            pulumi.String("Github - Workload Identity Pool"),
 		Disabled:               pulumi.Bool(false),
 		DisplayName:            pulumi.String(fmt.Sprintf("%s GitHub", formattedName)),
-		WorkloadIdentityPoolId: pulumi.String(fmt.Sprintf("%s-%s-github-pool-%s", projectConfig.ResourceNamePrefix, artifactRegistry.RegistryName, randomString)),
-	})
+		WorkloadIdentityPoolId: pulumi.Sprintf("%s-%s-github-pool-%s", projectConfig.ResourceNamePrefix, artifactRegistry.RegistryName, poolSuffix.Result),
+	}, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -34,3 +34,26 @@ func createRegistry(
 
 	return registry, err
 }
+
+// GrantRepositoryReader gives a member read access to a single repository instead of the whole project.
+func GrantRepositoryReader(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	registry *artifactregistry.Repository,
+	name string,
+	member pulumi.StringInput,
+) error {
+
+	resourceName := fmt.Sprintf("%s-%s-registry-reader", projectConfig.ResourceNamePrefix, name)
+	_, err := artifactregistry.NewRepositoryIamMember(ctx, resourceName, &artifactregistry.RepositoryIamMemberArgs{
+		Project:    registry.Project,
+		Location:   registry.Location,
+		Repository: registry.Name,
+		Role:       pulumi.String("roles/artifactregistry.reader"),
+		Member:     member,
+	}, pulumi.DependsOn([]pulumi.Resource{registry}))
+	if err != nil {
+		return global.NewResourceError("registry", resourceName, err)
+	}
+	return nil
+}
@@ -8,12 +8,12 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
-// SetupObjectStorage creates a GCS bucket and returns the outputs
+// CreateObjectStorage creates a GCS bucket and returns it
 func CreateObjectStorage(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	bucketName string,
-) *storage.Bucket {
+) (*storage.Bucket, error) {
 
 	bucketLocation := projectConfig.EnabledRegion.Region
 
@@ -32,7 +32,7 @@ func CreateObjectStorage(
 		UniformBucketLevelAccess: pulumi.Bool(true),
 	})
 	if err != nil {
-		ctx.Log.Error(fmt.Sprintf("Storage creation: %s", err), nil)
+		return nil, global.NewResourceError("storage", resourceName, err)
 	}
-	return bucket
+	return bucket, nil
 }
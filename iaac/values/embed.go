@@ -0,0 +1,10 @@
+// Package values embeds the default Helm values templates of every tool, so the program
+// does not depend on the directory it is launched from.
+package values
+
+import "embed"
+
+// Templates holds the values templates as `<tool>/<file>.yaml`.
+//
+//go:embed */*.yaml
+var Templates embed.FS
@@ -11,14 +11,14 @@ package vpc
 //    - Associates a health check with the backend service to ensure traffic is only sent to healthy instances.
 //    - Serves as a key component of the Global Load Balancer to manage backend resources effectively.
 //
-// 2. **TCP Health Checks (`createLoadBalancerTCPHealthChecks`)**:
-//    - Configures health checks that monitor backend services through TCP connections on a specified port (default: 80).
+// 2. **Health Checks (`createLoadBalancerHTTPSHealthCheck`)**:
+//    - Configures health checks that probe the nginx ingress controller pods on their `/healthz` endpoint (port 10254).
 //    - Ensures that only healthy backend instances receive traffic.
 //    - Uses parameters like `CheckIntervalSec`, `HealthyThreshold`, and `UnhealthyThreshold` to define how health checks operate.
 //
 // 3. **Backend Service (`createLoadbalancerBackendService`)**:
 //    - Defines the behavior of the Global Load Balancer, including connection draining and CDN caching policies.
-//    - Uses backend configurations to distribute traffic based on load balancing policies.
+//    - Routes to the zonal NEGs of the nginx ingress controller Service, or lets the AutoNEG controller attach them.
 //    - Tied to health checks to avoid routing requests to unhealthy instances.
 //
 // These resources work together to facilitate a **scalable, resilient, and highly available** architecture by enabling
@@ -33,29 +33,92 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
+var (
+	// Port of the nginx controller Service exposed through the NEGs; the backend service speaks HTTPS to it.
+	negPort = 443
+	// nginx serves its health endpoint on the controller pods, which NEG health checks probe directly.
+	nginxHealthCheckPort = 10254
+	nginxHealthCheckPath = "/healthz"
+	// Requests per second an nginx endpoint is expected to serve, used to balance across the NEGs.
+	negMaxRatePerEndpoint = 100.0
+)
+
+// NEGName returns the name of the standalone NEGs GKE creates for the nginx controller Service, one per zone.
+func NEGName(
+	projectConfig global.ProjectConfig,
+) string {
+	return fmt.Sprintf("%s-nginx-neg", projectConfig.ResourceNamePrefix)
+}
+
+// BackendServiceName returns the name of the Global Load Balancer backend service, which AutoNEG attaches the NEGs to.
+func BackendServiceName(
+	projectConfig global.ProjectConfig,
+) string {
+	return fmt.Sprintf("%s-backend-svc", projectConfig.ResourceNamePrefix)
+}
+
+// NEGAnnotations returns the annotations of the nginx controller Service that expose it through standalone NEGs,
+// and with AutoNEG, attach them to the backend service.
+func NEGAnnotations(
+	projectConfig global.ProjectConfig,
+) map[string]interface{} {
+
+	annotations := map[string]interface{}{
+		"cloud.google.com/neg": fmt.Sprintf(`{"exposed_ports": {"%d": {"name": "%s"}}}`, negPort, NEGName(projectConfig)),
+	}
+	if projectConfig.LoadBalancer.AutoNEG {
+		annotations["controller.autoneg.dev/neg"] = fmt.Sprintf(`{"backend_services": {"%d": [{"name": "%s", "max_rate_per_endpoint": %g}]}}`,
+			negPort, BackendServiceName(projectConfig), negMaxRatePerEndpoint)
+	}
+	return annotations
+}
+
 // CreateLoadBalancerBackendService sets up the backend service for a Global Load Balancer.
 // It creates a backend service that will handle incoming traffic routed by the load balancer.
 // This function also sets up the health checks used by the backend service.
 func createLoadBalancerBackendService(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
+	negZones pulumi.StringArrayInput,
+	opts ...pulumi.ResourceOption,
 ) (*compute.BackendService, error) {
 
 	gcpGLBTCPHealthCheck, err := createLoadBalancerHTTPSHealthCheck(ctx, projectConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Load Balancer Health check: %w", err)
 	}
-	gcpBackendService, err := createLoadbalancerBackendService(ctx, projectConfig, gcpGLBTCPHealthCheck.ID())
+	// With IAP, only the configured Google identities reach the backend service.
+	var iapSettings *compute.BackendServiceIapArgs
+	if projectConfig.IAP.Enabled {
+		iapSettings, err = createIAPClient(ctx, projectConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// With Cloud Armor, abusive traffic is dropped before reaching the backends.
+	var securityPolicy pulumi.StringPtrInput
+	if projectConfig.LoadBalancer.CloudArmor.Enabled {
+		policy, err := createSecurityPolicy(ctx, projectConfig)
+		if err != nil {
+			return nil, err
+		}
+		securityPolicy = policy.SelfLink
+	}
+	gcpBackendService, err := createLoadbalancerBackendService(ctx, projectConfig, gcpGLBTCPHealthCheck.ID(), iapSettings, securityPolicy, negZones, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Load Balancer Backend Service: %w", err)
 	}
+	if projectConfig.IAP.Enabled {
+		if err := grantIAPAccess(ctx, projectConfig, gcpBackendService); err != nil {
+			return nil, err
+		}
+	}
 
 	return gcpBackendService, nil
 }
 
-// createLoadBalancerTCPHealthChecks creates the TCP health checks that the Global Load Balancer will use
-// to verify the health of backend services. The health checks monitor the health of services
-// through TCP connections on a specified port.
+// createLoadBalancerHTTPSHealthCheck creates the health check the Global Load Balancer uses to verify
+// the nginx controller endpoints of the NEGs.
 func createLoadBalancerHTTPSHealthCheck(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
@@ -65,11 +128,11 @@ func createLoadBalancerHTTPSHealthCheck(
 	gcpGLBHealthCheck, err := compute.NewHealthCheck(ctx, resourceName, &compute.HealthCheckArgs{
 		Project:          pulumi.String(projectConfig.ProjectId),
 		CheckIntervalSec: pulumi.Int(10),
-		Description:      pulumi.String("HTTPS Health Check for Istio"),
+		Description:      pulumi.String("HTTP Health Check for the nginx Ingress Controller"),
 		HealthyThreshold: pulumi.Int(3),
 		HttpHealthCheck: &compute.HealthCheckHttpHealthCheckArgs{
-			Port:        pulumi.Int(15021), // Istio Gateway Health Check Port
-			RequestPath: pulumi.String("/healthz/ready"),
+			Port:        pulumi.Int(nginxHealthCheckPort),
+			RequestPath: pulumi.String(nginxHealthCheckPath),
 			ProxyHeader: pulumi.String("NONE"),
 		},
 		TimeoutSec:         pulumi.Int(5),
@@ -80,46 +143,61 @@ func createLoadBalancerHTTPSHealthCheck(
 }
 
 // createLoadbalancerBackendService creates a backend service for the Global Load Balancer
-// to route traffic from the GCP Load Balancer to Istio Ingress Gateway.
+// to route traffic from the GCP Load Balancer to the nginx Ingress Controller NEGs.
 func createLoadbalancerBackendService(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	gcpGLBHealthCheck pulumi.StringInput,
+	iapSettings *compute.BackendServiceIapArgs,
+	securityPolicy pulumi.StringPtrInput,
+	negZones pulumi.StringArrayInput,
+	opts ...pulumi.ResourceOption,
 ) (*compute.BackendService, error) {
 
-	// 🔹 Fetch the AutoNEG-managed NEG dynamically
-	// negSelfLink := getAutoNegNetworkEndpointGroup(ctx, projectConfig)
-
 	resourceName := fmt.Sprintf("%s-glb-backend", projectConfig.ResourceNamePrefix)
 
-	gcpBackendService, err := compute.NewBackendService(ctx, resourceName, &compute.BackendServiceArgs{
+	backendServiceArgs := &compute.BackendServiceArgs{
 		Project:                      pulumi.String(projectConfig.ProjectId),
-		Name:                         pulumi.String(fmt.Sprintf("%s-backend-svc", projectConfig.ResourceNamePrefix)),
+		Name:                         pulumi.String(BackendServiceName(projectConfig)),
 		Description:                  pulumi.String("Global Load Balancer - Backend Service"),
 		EnableCdn:                    pulumi.Bool(false),
 		ConnectionDrainingTimeoutSec: pulumi.Int(10),
 		HealthChecks:                 gcpGLBHealthCheck,
 		Protocol:                     pulumi.String("HTTPS"),
 		LoadBalancingScheme:          pulumi.String("EXTERNAL"),
-
-		// Backend routing to Istio Ingress Gateway
-		Backends: compute.BackendServiceBackendArray{},
-	})
+		SecurityPolicy:               securityPolicy,
+	}
+	if projectConfig.LoadBalancer.AutoNEG {
+		// AutoNEG adds and removes the NEGs itself.
+		opts = append(opts, pulumi.IgnoreChanges([]string{"backends"}))
+	} else {
+		backendServiceArgs.Backends = negBackends(projectConfig, negZones)
+	}
+	if iapSettings != nil {
+		backendServiceArgs.Iap = iapSettings
+	}
+	gcpBackendService, err := compute.NewBackendService(ctx, resourceName, backendServiceArgs, opts...)
 	return gcpBackendService, err
 }
 
-// func getAutoNegNetworkEndpointGroup(
-// 	ctx *pulumi.Context,
-// 	projectConfig global.ProjectConfig,
-// ) pulumi.StringOutput {
-// 	// Construct the expected AutoNEG-managed NEG name
-// 	negName := pulumi.Sprintf("k8s1-%s-istio-ingressgateway", projectConfig.ProjectId)
-
-// 	// Retrieve the NEG dynamically
-// 	neg := compute.LookupNetworkEndpointGroupOutput(ctx, compute.LookupNetworkEndpointGroupOutputArgs{
-// 		Name:    negName,
-// 		Project: pulumi.String(projectConfig.ProjectId),
-// 		Zone:    pulumi.String("europe-west8"),
-// 	})
-// 	return pulumi.StringOutput(neg.SelfLink())
-// }
+// negBackends returns a backend for the nginx controller NEG of every zone the nodes run in.
+func negBackends(
+	projectConfig global.ProjectConfig,
+	negZones pulumi.StringArrayInput,
+) compute.BackendServiceBackendArrayOutput {
+
+	return negZones.ToStringArrayOutput().ApplyT(func(zones []string) []compute.BackendServiceBackend {
+		balancingMode := "RATE"
+		maxRate := negMaxRatePerEndpoint
+		var backends []compute.BackendServiceBackend
+		for _, zone := range zones {
+			backends = append(backends, compute.BackendServiceBackend{
+				Group: fmt.Sprintf("projects/%s/zones/%s/networkEndpointGroups/%s",
+					projectConfig.ProjectId, zone, NEGName(projectConfig)),
+				BalancingMode:      &balancingMode,
+				MaxRatePerEndpoint: &maxRate,
+			})
+		}
+		return backends
+	}).(compute.BackendServiceBackendArrayOutput)
+}
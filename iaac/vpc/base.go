@@ -6,7 +6,7 @@ package vpc
 // Backend Service = The "Traffic Distribution System"
 
 import (
-	"fmt"
+	"mlops/dns"
 	"mlops/global"
 
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/compute"
@@ -14,6 +14,9 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
 )
 
+// Subsystem reported by the errors of the network and load balancer resources.
+var subsystem = "vpc"
+
 // CreateVPCResources provisions a VPC network along with necessary load balancing resources.
 // It sets up the network (`createVPC`), backend service, global static IP, and optionally configures SSL certificates.
 // The function also creates a URL map for HTTP traffic, ensuring proper request routing.
@@ -25,7 +28,7 @@ func CreateVPCResources(
 
 	gcpNetwork, err := createVPC(ctx, projectConfig)
 	if err != nil {
-		return nil, err
+		return nil, global.NewResourceError(subsystem, "network", err)
 	}
 	return gcpNetwork, nil
 }
@@ -39,49 +42,68 @@ func CreateVPCSubnetResources(
 
 	gcpSubnetwork, err := createVPCSubnet(ctx, projectConfig, region, gcpNetwork)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create subnetwork: %w", err)
+		return nil, global.NewResourceError(subsystem, "subnetwork", err)
 	}
 
 	if config.GetBool(ctx, "gke:privateNodes") {
 		cloudRouter, err := createCloudRouter(ctx, projectConfig, region, gcpNetwork)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create router: %w", err)
+			return nil, global.NewResourceError(subsystem, "router", err)
 		}
 		err = createCloudNAT(ctx, projectConfig, region, cloudRouter)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create NAT: %w", err)
+			return nil, global.NewResourceError(subsystem, "NAT", err)
 		}
 		err = createFirewallEgress(ctx, projectConfig, gcpNetwork)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create Firewall Egress: %w", err)
+			return nil, global.NewResourceError(subsystem, "egress firewall", err)
 		}
 		return gcpSubnetwork, nil
 	}
 	return gcpSubnetwork, nil
 }
 
-func CreateBackendServiceResources(
+// CreateLoadBalancerAddress reserves the global address of the Global Load Balancer and records the domain on it.
+// It is created ahead of the tools, so their hostnames can be recorded on it too.
+func CreateLoadBalancerAddress(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
-) (*compute.BackendService, error) {
+) (*compute.GlobalAddress, error) {
 
-	gcpBackendService, err := createLoadBalancerBackendService(ctx, projectConfig)
+	gcpGlobalAddress, err := createLoadBalancerStaticIP(ctx, projectConfig)
 	if err != nil {
-		return nil, err
+		return nil, global.NewResourceError(subsystem, "global address", err)
 	}
-	gcpGlobalAddress, err := createLoadBalancerStaticIP(ctx, projectConfig)
+	if err := dns.CreateRecord(ctx, projectConfig, projectConfig.Domain, gcpGlobalAddress.Address); err != nil {
+		return nil, global.NewResourceError(subsystem, "domain record", err)
+	}
+	return gcpGlobalAddress, nil
+}
+
+// CreateBackendServiceResources routes the Global Load Balancer to the nginx ingress controller NEGs of the zones
+// in negZones, for the given hostnames. The NEGs only exist once the controller is installed, so opts must depend on it.
+func CreateBackendServiceResources(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	gcpGlobalAddress *compute.GlobalAddress,
+	hosts []string,
+	negZones pulumi.StringArrayInput,
+	opts ...pulumi.ResourceOption,
+) (*compute.BackendService, error) {
+
+	gcpBackendService, err := createLoadBalancerBackendService(ctx, projectConfig, negZones, opts...)
 	if err != nil {
-		return nil, err
+		return nil, global.NewResourceError(subsystem, BackendServiceName(projectConfig), err)
 	}
 	if projectConfig.SSL {
-		err = configureSSLCertificate(ctx, projectConfig, gcpBackendService, gcpGlobalAddress)
+		err = configureSSLCertificate(ctx, projectConfig, gcpBackendService, gcpGlobalAddress, hosts)
 		if err != nil {
-			return nil, err
+			return nil, global.NewResourceError(subsystem, "HTTPS frontend", err)
 		}
 	}
-	err = createLoadBalancerURLMapHTTP(ctx, projectConfig, gcpGlobalAddress, gcpBackendService)
+	err = createLoadBalancerURLMapHTTP(ctx, projectConfig, gcpGlobalAddress, gcpBackendService, hosts)
 	if err != nil {
-		return nil, err
+		return nil, global.NewResourceError(subsystem, "HTTP frontend", err)
 	}
 	return gcpBackendService, nil
 }
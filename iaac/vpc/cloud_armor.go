@@ -0,0 +1,129 @@
+package vpc
+
+import (
+	"fmt"
+	"mlops/global"
+	"strings"
+
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/compute"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var (
+	// Rules are evaluated by ascending priority: WAF, then regions, then the allowlisted clients.
+	wafRulePriority     = 1000
+	regionRulePriority  = 2000
+	clientRulePriority  = 3000
+	defaultRulePriority = 2147483647
+	// Cloud Armor accepts at most 10 IP ranges per rule.
+	maxSrcIpRangesPerRule = 10
+	rateLimitIntervalSec  = 60
+)
+
+// createSecurityPolicy creates the Cloud Armor policy of the backend service, so abusive traffic is dropped at
+// Google's edge: preconfigured WAF rules, allowed regions, and the `project:whitelistedIPs` clients, throttled
+// per IP when `cloudArmor:rateLimit` is set. Any other request is denied.
+func createSecurityPolicy(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+) (*compute.SecurityPolicy, error) {
+
+	cloudArmor := projectConfig.LoadBalancer.CloudArmor
+	var rules compute.SecurityPolicyRuleTypeArray
+
+	for i, wafRule := range cloudArmor.WAFRules {
+		rules = append(rules, &compute.SecurityPolicyRuleTypeArgs{
+			Action:      pulumi.String("deny(403)"),
+			Priority:    pulumi.Int(wafRulePriority + i),
+			Description: pulumi.String(fmt.Sprintf("WAF - %s", wafRule)),
+			Match: &compute.SecurityPolicyRuleMatchArgs{
+				Expr: &compute.SecurityPolicyRuleMatchExprArgs{
+					Expression: pulumi.String(fmt.Sprintf("evaluatePreconfiguredWaf('%s', {'sensitivity': %d})", wafRule, cloudArmor.WAFSensitivity)),
+				},
+			},
+		})
+	}
+
+	if len(cloudArmor.AllowedRegions) > 0 {
+		var conditions []string
+		for _, region := range cloudArmor.AllowedRegions {
+			conditions = append(conditions, fmt.Sprintf("origin.region_code == '%s'", region))
+		}
+		rules = append(rules, &compute.SecurityPolicyRuleTypeArgs{
+			Action:      pulumi.String("deny(403)"),
+			Priority:    pulumi.Int(regionRulePriority),
+			Description: pulumi.String("Regions - deny requests from outside the allowed regions"),
+			Match: &compute.SecurityPolicyRuleMatchArgs{
+				Expr: &compute.SecurityPolicyRuleMatchExprArgs{
+					Expression: pulumi.String(fmt.Sprintf("!(%s)", strings.Join(conditions, " || "))),
+				},
+			},
+		})
+	}
+
+	clientRanges := global.FormatStringIntoList(projectConfig.WhitelistedIPs)
+	for i := 0; i*maxSrcIpRangesPerRule < len(clientRanges); i++ {
+		end := (i + 1) * maxSrcIpRangesPerRule
+		if end > len(clientRanges) {
+			end = len(clientRanges)
+		}
+		rules = append(rules, clientRule(cloudArmor, clientRulePriority+i, clientRanges[i*maxSrcIpRangesPerRule:end]))
+	}
+
+	rules = append(rules, &compute.SecurityPolicyRuleTypeArgs{
+		Action:      pulumi.String("deny(403)"),
+		Priority:    pulumi.Int(defaultRulePriority),
+		Description: pulumi.String("Default - deny clients outside the allowlist"),
+		Match: &compute.SecurityPolicyRuleMatchArgs{
+			VersionedExpr: pulumi.String("SRC_IPS_V1"),
+			Config: &compute.SecurityPolicyRuleMatchConfigArgs{
+				SrcIpRanges: pulumi.StringArray{pulumi.String("*")},
+			},
+		},
+	})
+
+	resourceName := fmt.Sprintf("%s-glb-security-policy", projectConfig.ResourceNamePrefix)
+	securityPolicy, err := compute.NewSecurityPolicy(ctx, resourceName, &compute.SecurityPolicyArgs{
+		Project:     pulumi.String(projectConfig.ProjectId),
+		Name:        pulumi.String(resourceName),
+		Description: pulumi.String("Global Load Balancer - Cloud Armor Security Policy"),
+		Rules:       rules,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Armor security policy: %w", err)
+	}
+	return securityPolicy, nil
+}
+
+// clientRule allows the given allowlisted client ranges, throttling each client IP above the rate limit.
+func clientRule(
+	cloudArmor global.CloudArmorConfig,
+	priority int,
+	srcIpRanges []string,
+) *compute.SecurityPolicyRuleTypeArgs {
+
+	rule := &compute.SecurityPolicyRuleTypeArgs{
+		Action:      pulumi.String("allow"),
+		Priority:    pulumi.Int(priority),
+		Description: pulumi.String("Clients - allowlisted IP ranges"),
+		Match: &compute.SecurityPolicyRuleMatchArgs{
+			VersionedExpr: pulumi.String("SRC_IPS_V1"),
+			Config: &compute.SecurityPolicyRuleMatchConfigArgs{
+				SrcIpRanges: pulumi.ToStringArray(srcIpRanges),
+			},
+		},
+	}
+	if cloudArmor.RateLimit > 0 {
+		rule.Action = pulumi.String("throttle")
+		rule.RateLimitOptions = &compute.SecurityPolicyRuleRateLimitOptionsArgs{
+			ConformAction: pulumi.String("allow"),
+			ExceedAction:  pulumi.String("deny(429)"),
+			EnforceOnKey:  pulumi.String("IP"),
+			RateLimitThreshold: &compute.SecurityPolicyRuleRateLimitOptionsRateLimitThresholdArgs{
+				Count:       pulumi.Int(cloudArmor.RateLimit),
+				IntervalSec: pulumi.Int(rateLimitIntervalSec),
+			},
+		}
+	}
+	return rule
+}
@@ -8,7 +8,7 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
-// createFirewallRuleHealthChecks creates a firewall rule that allows incoming TCP traffic (ports 80, 8080, 443) for health checks used by services like load balancers.
+// createFirewallRuleHealthChecks creates a firewall rule that allows incoming TCP traffic (ports 80, 8080, 443, 10254) for health checks used by services like load balancers.
 // The allowed source ranges are from IP blocks 35.191.0.0/16 and 130.211.0.0/22, which are Google Cloud’s health check sources (https://cloud.google.com/load-balancing/docs/health-check-concepts#ip-ranges).
 func createFirewallRuleHealthChecks(
 	ctx *pulumi.Context,
@@ -29,6 +29,7 @@ func createFirewallRuleHealthChecks(
 					pulumi.String("80"),
 					pulumi.String("8080"),
 					pulumi.String("443"),
+					pulumi.String("10254"), // nginx controller health endpoint, probed on the NEG endpoints
 				},
 			},
 		},
@@ -0,0 +1,67 @@
+package vpc
+
+import (
+	"fmt"
+	"mlops/global"
+
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/compute"
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/iap"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// createIAPClient creates the OAuth client IAP authenticates the Global Load Balancer users with.
+// A project holds a single brand, so an existing one ( `iap:brand` ) is reused instead of created.
+func createIAPClient(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+) (*compute.BackendServiceIapArgs, error) {
+
+	brandName := pulumi.String(projectConfig.IAP.Brand).ToStringOutput()
+	if projectConfig.IAP.Brand == "" {
+		resourceName := fmt.Sprintf("%s-iap-brand", projectConfig.ResourceNamePrefix)
+		brand, err := iap.NewBrand(ctx, resourceName, &iap.BrandArgs{
+			Project:          pulumi.String(projectConfig.ProjectId),
+			ApplicationTitle: pulumi.String(fmt.Sprintf("%s MLOps", projectConfig.ResourceNamePrefix)),
+			SupportEmail:     pulumi.String(projectConfig.IAP.SupportEmail),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create IAP brand: %w", err)
+		}
+		brandName = brand.Name
+	}
+
+	resourceName := fmt.Sprintf("%s-iap-client", projectConfig.ResourceNamePrefix)
+	client, err := iap.NewClient(ctx, resourceName, &iap.ClientArgs{
+		Brand:       brandName,
+		DisplayName: pulumi.String(fmt.Sprintf("%s Global Load Balancer", projectConfig.ResourceNamePrefix)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IAP OAuth client: %w", err)
+	}
+	return &compute.BackendServiceIapArgs{
+		Oauth2ClientId:     client.ClientId,
+		Oauth2ClientSecret: client.Secret,
+	}, nil
+}
+
+// grantIAPAccess grants the configured members access to the backend service through IAP.
+func grantIAPAccess(
+	ctx *pulumi.Context,
+	projectConfig global.ProjectConfig,
+	gcpBackendService *compute.BackendService,
+) error {
+
+	for _, member := range projectConfig.IAP.Members {
+		resourceName := fmt.Sprintf("%s-iap-accessor-%s", projectConfig.ResourceNamePrefix, member)
+		_, err := iap.NewWebBackendServiceIamMember(ctx, resourceName, &iap.WebBackendServiceIamMemberArgs{
+			Project:           pulumi.String(projectConfig.ProjectId),
+			WebBackendService: gcpBackendService.Name,
+			Role:              pulumi.String("roles/iap.httpsResourceAccessor"),
+			Member:            pulumi.String(member),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to grant IAP access to %s: %w", member, err)
+		}
+	}
+	return nil
+}
@@ -7,10 +7,10 @@ package vpc
 //
 // The resources created in this package include:
 //
-// 1. **Managed SSL Certificate (`createManagedSSLCertificate`)**:
-//    - Generates a **Google-managed SSL certificate** for HTTPS traffic on the Global Load Balancer.
-//    - Automatically provisions and renews SSL certificates for the specified domain.
-//    - Ensures secure communication by encrypting data in transit.
+// 1. **Certificate Map (`createCertificateMap`)**:
+//    - Issues a **Certificate Manager** certificate for the domain and its `*.<domain>` subdomains,
+//      authorised through a DNS record so every tool host is covered, including ones added later.
+//    - Serves the certificate through a **certificate map** with an entry for the apex and one for the subdomains.
 //
 // 2. **HTTPS URL Map (`createLoadbalancerURLMapHTTPS`)**:
 //    - Defines **URL routing rules** for the HTTPS Global Load Balancer.
@@ -19,7 +19,7 @@ package vpc
 //
 // 3. **Target HTTPS Proxy (`createLoadbalancerHTTPSProxy`)**:
 //    - Acts as an intermediary that processes **HTTPS requests** before forwarding them to backend services.
-//    - Uses the **managed SSL certificate** to terminate SSL and establish a secure connection.
+//    - Uses the **certificate map** to terminate SSL, so a new host does not replace the proxy.
 //    - Ensures traffic is encrypted when reaching the load balancer.
 //
 // 4. **HTTPS Forwarding Rule (`createLoadBalancerForwardingRule`)**:
@@ -28,36 +28,39 @@ package vpc
 //    - Ensures that clients always connect using secure HTTPS.
 //
 // These resources work together to enable **secure, scalable, and highly available** HTTPS traffic
-// management for applications running in Google Cloud. By integrating **Google-managed SSL certificates**,
+// management for applications running in Google Cloud. By integrating **Certificate Manager certificates**,
 // the setup ensures **automatic certificate renewal**, reducing operational overhead and improving security.
 
 import (
 	"fmt"
+	"mlops/dns"
 	"mlops/global"
 
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/certificatemanager"
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/compute"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
 // configureSSLCertificate configures the SSL certificate and related resources for a Global Load Balancer (GLB) with HTTPS support.
-// It creates a managed SSL certificate, a URL map for HTTPS traffic, a Target HTTPS Proxy, and a Global Forwarding Rule for HTTPS traffic.
+// It creates a certificate map, a URL map for HTTPS traffic, a Target HTTPS Proxy, and a Global Forwarding Rule for HTTPS traffic.
 // It ensures all necessary dependencies for the resources are set, including the SSL certificate and backend service.
 func configureSSLCertificate(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	gcpBackendService *compute.BackendService,
 	gcpGlobalAddress *compute.GlobalAddress,
+	hosts []string,
 ) error {
 
-	gcpGLBManagedSSLCert, err := createManagedSSLCertificate(ctx, projectConfig)
+	gcpCertificateMap, err := createCertificateMap(ctx, projectConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create Managed SSL Certificate: %w", err)
+		return fmt.Errorf("failed to create Certificate Map: %w", err)
 	}
-	gcpGLBURLMapHTTPS, err := createLoadbalancerURLMapHTTPS(ctx, projectConfig, gcpBackendService)
+	gcpGLBURLMapHTTPS, err := createLoadbalancerURLMapHTTPS(ctx, projectConfig, gcpBackendService, hosts)
 	if err != nil {
 		return fmt.Errorf("failed to create Load Balancer URL Map HTPS: %w", err)
 	}
-	gcpGLBTargetHTTPSProxy, err := createLoadbalancerHTTPSProxy(ctx, projectConfig, gcpGLBURLMapHTTPS, gcpGLBManagedSSLCert)
+	gcpGLBTargetHTTPSProxy, err := createLoadbalancerHTTPSProxy(ctx, projectConfig, gcpGLBURLMapHTTPS, gcpCertificateMap)
 	if err != nil {
 		return fmt.Errorf("failed to create Load Balancer HTPS Proxy: %w", err)
 	}
@@ -68,36 +71,89 @@ func configureSSLCertificate(
 	return nil
 }
 
-// createManagedSSLCertificate creates a Managed SSL Certificate resource for use with the Global Load Balancer.
-// It takes in the domain name and project details, and sets up the SSL certificate for HTTPS traffic handling.
-// This function requires the configuration of dependencies like the backend service to ensure it is correctly created before being used in the load balancer setup.
-func createManagedSSLCertificate(
+// createCertificateMap issues a Certificate Manager certificate for the domain and `*.<domain>`, authorised by DNS,
+// and serves it through a certificate map. The CNAME record of the authorization is created in the Cloud DNS zone
+// when one is configured, else it is exported to be added at the DNS provider.
+func createCertificateMap(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
-) (*compute.ManagedSslCertificate, error) {
+) (*certificatemanager.CertificateMapResource, error) {
 
-	resourceName := fmt.Sprintf("%s-glb-ssl-cert", projectConfig.ResourceNamePrefix)
-	gcpGLBManagedSSLCert, err := compute.NewManagedSslCertificate(ctx, resourceName, &compute.ManagedSslCertificateArgs{
+	resourceName := fmt.Sprintf("%s-glb-dns-authorization", projectConfig.ResourceNamePrefix)
+	dnsAuthorization, err := certificatemanager.NewDnsAuthorization(ctx, resourceName, &certificatemanager.DnsAuthorizationArgs{
 		Project:     pulumi.String(projectConfig.ProjectId),
 		Name:        pulumi.String(resourceName),
-		Description: pulumi.String("Global Load Balancer - Managed SSL Certificate"),
-		Type:        pulumi.String("MANAGED"),
-		Managed: &compute.ManagedSslCertificateManagedArgs{
+		Description: pulumi.String("Global Load Balancer - DNS Authorization"),
+		Domain:      pulumi.String(projectConfig.Domain),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if projectConfig.DNS.Enabled {
+		err = dns.CreateAuthorizationRecord(ctx, projectConfig, "glb", dnsAuthorization.DnsResourceRecords)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ctx.Export("glbDNSAuthorizationRecords", dnsAuthorization.DnsResourceRecords)
+	}
+
+	// The wildcard covers every tool subdomain, so adding a host needs no new certificate.
+	resourceName = fmt.Sprintf("%s-glb-certificate", projectConfig.ResourceNamePrefix)
+	certificate, err := certificatemanager.NewCertificate(ctx, resourceName, &certificatemanager.CertificateArgs{
+		Project:     pulumi.String(projectConfig.ProjectId),
+		Name:        pulumi.String(resourceName),
+		Description: pulumi.String("Global Load Balancer - Managed Certificate"),
+		Managed: &certificatemanager.CertificateManagedArgs{
 			Domains: pulumi.StringArray{
-				pulumi.String(projectConfig.Domain), // Uses the Domain provided
+				pulumi.String(projectConfig.Domain),
+				pulumi.Sprintf("*.%s", projectConfig.Domain),
 			},
+			DnsAuthorizations: pulumi.StringArray{dnsAuthorization.ID()},
 		},
 	})
-	return gcpGLBManagedSSLCert, err
+	if err != nil {
+		return nil, err
+	}
+
+	resourceName = fmt.Sprintf("%s-glb-certificate-map", projectConfig.ResourceNamePrefix)
+	certificateMap, err := certificatemanager.NewCertificateMapResource(ctx, resourceName, &certificatemanager.CertificateMapResourceArgs{
+		Project:     pulumi.String(projectConfig.ProjectId),
+		Name:        pulumi.String(resourceName),
+		Description: pulumi.String("Global Load Balancer - Certificate Map"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hostnames := map[string]string{
+		"apex":     projectConfig.Domain,
+		"wildcard": fmt.Sprintf("*.%s", projectConfig.Domain),
+	}
+	for _, entry := range []string{"apex", "wildcard"} {
+		resourceName = fmt.Sprintf("%s-glb-certificate-map-%s", projectConfig.ResourceNamePrefix, entry)
+		_, err = certificatemanager.NewCertificateMapEntry(ctx, resourceName, &certificatemanager.CertificateMapEntryArgs{
+			Project:      pulumi.String(projectConfig.ProjectId),
+			Name:         pulumi.String(resourceName),
+			Map:          certificateMap.Name,
+			Hostname:     pulumi.String(hostnames[entry]),
+			Certificates: pulumi.StringArray{certificate.ID()},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return certificateMap, nil
 }
 
 // createLoadbalancerURLMapHTTPS creates a URL Map resource for handling HTTPS traffic within a Global Load Balancer setup.
-// The URL Map routes incoming requests to the appropriate backend service based on the URL pattern and the domain specified.
+// The URL Map routes the hostnames of the tools to the backend service; nginx then routes them by their ingresses.
 // This function helps define the routing behavior for SSL/TLS traffic, ensuring secure requests are directed to the correct backend.
 func createLoadbalancerURLMapHTTPS(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	gcpBackendService *compute.BackendService,
+	hosts []string,
 ) (*compute.URLMap, error) {
 
 	resourceName := fmt.Sprintf("%s-glb-url-map-https-domain", projectConfig.ResourceNamePrefix)
@@ -105,19 +161,21 @@ func createLoadbalancerURLMapHTTPS(
 		Project:        pulumi.String(projectConfig.ProjectId),
 		Name:           pulumi.String(fmt.Sprintf("%s-glb-urlmap-https", projectConfig.ResourceNamePrefix)),
 		Description:    pulumi.String("Global Load Balancer - HTTPS URL Map"),
+		HostRules:      hostRules(hosts),
+		PathMatchers:   pathMatchers(hosts, gcpBackendService),
 		DefaultService: gcpBackendService.SelfLink, // Points to the Backend service
 	}, pulumi.DependsOn([]pulumi.Resource{gcpBackendService}))
 	return gcpGLBURLMapHTTPS, err
 }
 
 // createLoadbalancerHTTPSProxy creates a Target HTTPS Proxy resource to handle incoming HTTPS requests.
-// The proxy uses the previously created URL Map and Managed SSL Certificate to process secure traffic.
+// The proxy uses the previously created URL Map and Certificate Map to process secure traffic.
 // The HTTPS Proxy is essential for directing traffic securely through the Global Load Balancer and to the proper backend service.
 func createLoadbalancerHTTPSProxy(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	gcpGLBURLMapHTTPS *compute.URLMap,
-	gcpGLBManagedSSLCert *compute.ManagedSslCertificate,
+	gcpCertificateMap *certificatemanager.CertificateMapResource,
 ) (*compute.TargetHttpsProxy, error) {
 
 	resourceName := fmt.Sprintf("%s-glb-https-proxy", projectConfig.ResourceNamePrefix)
@@ -125,9 +183,8 @@ func createLoadbalancerHTTPSProxy(
 		Project: pulumi.String(projectConfig.ProjectId),
 		Name:    pulumi.String(resourceName),
 		UrlMap:  gcpGLBURLMapHTTPS.SelfLink, // Routing
-		SslCertificates: pulumi.StringArray{
-			gcpGLBManagedSSLCert.SelfLink, // Uses the Managed SSL Cert
-		},
-	}, pulumi.DependsOn([]pulumi.Resource{gcpGLBManagedSSLCert, gcpGLBURLMapHTTPS}))
+		// Entries of the map can change without replacing the proxy.
+		CertificateMap: pulumi.Sprintf("//certificatemanager.googleapis.com/%s", gcpCertificateMap.ID()),
+	}, pulumi.DependsOn([]pulumi.Resource{gcpCertificateMap, gcpGLBURLMapHTTPS}))
 	return gcpGLBTargetHTTPSProxy, err
 }
@@ -42,6 +42,7 @@ func createLoadBalancerURLMapHTTP(
 	projectConfig global.ProjectConfig,
 	gcpGlobalAddress *compute.GlobalAddress,
 	gcpBackendService *compute.BackendService,
+	hosts []string,
 ) error {
 
 	var gcpGLBURLMapHTTP *compute.URLMap
@@ -53,7 +54,7 @@ func createLoadBalancerURLMapHTTP(
 			return fmt.Errorf("failed to create Load Balancer URL HTTP Map [ No Domain ]: %w", err)
 		}
 	} else {
-		gcpGLBURLMapHTTP, err = createLoadBalancerURLMapHTTPWithDomain(ctx, projectConfig, gcpBackendService)
+		gcpGLBURLMapHTTP, err = createLoadBalancerURLMapHTTPWithDomain(ctx, projectConfig, gcpBackendService, hosts)
 		if err != nil {
 			return fmt.Errorf("failed to create Load Balancer URL HTTP Map [ Domain ]: %w", err)
 		}
@@ -88,11 +89,12 @@ func createLoadBalancerURLMapHTTPWithNoDomain(
 }
 
 // createLoadBalancerURLMapHTTPWithDomain creates a URL map for HTTP traffic with a specific domain.
-// The URL map routes traffic based on the provided domain and any additional path-based routing rules.
+// The URL map routes traffic based on the domain and tool hostnames and any additional path-based routing rules.
 func createLoadBalancerURLMapHTTPWithDomain(
 	ctx *pulumi.Context,
 	projectConfig global.ProjectConfig,
 	gcpBackendService *compute.BackendService,
+	hosts []string,
 ) (*compute.URLMap, error) {
 
 	resourceName := fmt.Sprintf("%s-glb-url-map-http-domain", projectConfig.ResourceNamePrefix)
@@ -102,9 +104,7 @@ func createLoadBalancerURLMapHTTPWithDomain(
 		Description: pulumi.String("Global Load Balancer - HTTP URL Map"),
 		HostRules: &compute.URLMapHostRuleArray{
 			&compute.URLMapHostRuleArgs{
-				Hosts: pulumi.StringArray{
-					pulumi.String(projectConfig.Domain),
-				},
+				Hosts:       pulumi.ToStringArray(append([]string{projectConfig.Domain}, hosts...)),
 				PathMatcher: pulumi.String("all-paths"),
 				Description: pulumi.String("Default Route All Paths"),
 			},
@@ -148,3 +148,37 @@ func createLoadBalancerHTTPProxy(
 	})
 	return gcpGLBTargetHTTPProxy, err
 }
+
+// hostRules routes every tool hostname to the `tools` path matcher.
+func hostRules(
+	hosts []string,
+) compute.URLMapHostRuleArray {
+
+	if len(hosts) == 0 {
+		return nil
+	}
+	return compute.URLMapHostRuleArray{
+		&compute.URLMapHostRuleArgs{
+			Hosts:       pulumi.ToStringArray(hosts),
+			PathMatcher: pulumi.String("tools"),
+			Description: pulumi.String("Tool hostnames, routed by the nginx ingresses"),
+		},
+	}
+}
+
+// pathMatchers sends all paths of the tool hostnames to the nginx ingress controller backend service.
+func pathMatchers(
+	hosts []string,
+	gcpBackendService *compute.BackendService,
+) compute.URLMapPathMatcherArray {
+
+	if len(hosts) == 0 {
+		return nil
+	}
+	return compute.URLMapPathMatcherArray{
+		&compute.URLMapPathMatcherArgs{
+			Name:           pulumi.String("tools"),
+			DefaultService: gcpBackendService.SelfLink,
+		},
+	}
+}
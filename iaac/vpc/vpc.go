@@ -37,10 +37,9 @@ import (
 )
 
 var (
-	GoogleCloudIPRange = pulumi.StringArray{
-		pulumi.String("35.191.0.0/16"),
-		pulumi.String("130.211.0.0/22"),
-	}
+	// GoogleFrontEndIPRanges are the sources of the Google Front End proxies and health checks.
+	GoogleFrontEndIPRanges = []string{"35.191.0.0/16", "130.211.0.0/22"}
+	GoogleCloudIPRange     = pulumi.ToStringArray(GoogleFrontEndIPRanges)
 )
 
 // createVPC Function is function is responsible for the creation of a VPC Network using the createVPCNetwork function.